@@ -0,0 +1,55 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:JSONPath=".status.conditions[?(@.type==\"Ready\")].status",name=Ready,type=string
+//+kubebuilder:printcolumn:JSONPath=".status.appCount",name=Apps,type=integer
+//+kubebuilder:printcolumn:JSONPath=".spec.deploymentConfig.runtimeClassName",name=RuntimeClass,type=string
+//+kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name=Age,type=date
+
+// ClusterSpinAppExecutor is the Schema for the clusterspinappexecutors API.
+//
+// A ClusterSpinAppExecutor is available to SpinApps in every namespace. When a
+// SpinAppExecutor with the same name exists in the namespace of an app, the
+// namespaced executor takes precedence.
+type ClusterSpinAppExecutor struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SpinAppExecutorSpec   `json:"spec,omitempty"`
+	Status SpinAppExecutorStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterSpinAppExecutorList contains a list of ClusterSpinAppExecutor
+type ClusterSpinAppExecutorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterSpinAppExecutor `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterSpinAppExecutor{}, &ClusterSpinAppExecutorList{})
+}
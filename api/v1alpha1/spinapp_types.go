@@ -17,19 +17,29 @@ limitations under the License.
 package v1alpha1
 
 import (
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // SpinAppSpec defines the desired state of SpinApp
 //
 // +kubebuilder:validation:Optional
 type SpinAppSpec struct {
-	// Executor controls how this app is executed in the cluster.
+	// Executor controls how this app is executed in the cluster. It refers to
+	// a SpinAppExecutor in the namespace of the app, or a ClusterSpinAppExecutor
+	// when no namespaced executor with that name exists.
 	//
-	// Defaults to whatever executor is available on the cluster. If multiple
-	// executors are available then the first executor in alphabetical order
-	// will be chosen. If no executors are available then no default will be set.
+	// Defaults to the executor annotated with
+	// `core.spinkube.dev/is-default-executor: "true"` in the namespace, falling
+	// back to an annotated cluster executor. Without an annotated executor,
+	// whatever executor is available in the namespace (or else the cluster) is
+	// used. If multiple executors are available then the first executor in
+	// alphabetical order will be chosen and a warning is returned. If no
+	// executors are available then no default will be set.
 	Executor string `json:"executor"`
 
 	// Image is the source for this app.
@@ -44,16 +54,56 @@ type SpinAppSpec struct {
 	Checks HealthChecks `json:"checks,omitempty"`
 
 	// Number of replicas to run.
+	//
+	// This is exposed through the scale subresource, so autoscalers can target
+	// the SpinApp directly.
 	Replicas int32 `json:"replicas,omitempty"`
 
 	// EnableAutoscaling indicates whether the app is allowed to autoscale. If
-	// true then the operator leaves the replica count of the underlying
-	// deployment to be managed by an external autoscaler (HPA/KEDA). Replicas
-	// cannot be defined if this is enabled. By default EnableAutoscaling is false.
+	// true then replicas are managed by an external autoscaler (HPA/KEDA).
+	// AutoscalerTarget tells the operator whether the autoscaler targets the
+	// SpinApp or the underlying deployment. Replicas cannot be defined if this
+	// is enabled, unless the autoscaler targets the SpinApp. By default
+	// EnableAutoscaling is false.
 	//
 	// +kubebuilder:default:=false
 	EnableAutoscaling bool `json:"enableAutoscaling,omitempty"`
 
+	// AutoscalerTarget is the resource an external autoscaler of the app
+	// targets, one of Deployment or SpinApp. Autoscalers targeting the SpinApp
+	// set replicas through its scale subresource, and the operator propagates
+	// it to the underlying deployment. Replicas is the replica count to start
+	// from in that case, as an HPA doesn't scale targets that have zero
+	// replicas. Otherwise the operator leaves the replica count of the
+	// underlying deployment to the autoscaler. Defaults to Deployment.
+	//
+	// This can only be set when EnableAutoscaling is true and Autoscaling isn't
+	// set, as the autoscalers managed by the operator always target the
+	// SpinApp.
+	//
+	// +kubebuilder:validation:Enum=Deployment;SpinApp
+	AutoscalerTarget AutoscalerTarget `json:"autoscalerTarget,omitempty"`
+
+	// Autoscaling configures a HorizontalPodAutoscaler that is created and
+	// managed by the operator for this app, instead of providing your own. It
+	// targets the app through its scale subresource, starting from the minimum
+	// replica count. EnableAutoscaling must be true when this is set.
+	Autoscaling *Autoscaling `json:"autoscaling,omitempty"`
+
+	// Suspend scales the app to zero while keeping its configuration and
+	// Service. Autoscalers created by the operator are removed while the app
+	// is suspended, and the replica count or autoscaling is restored when it
+	// is resumed.
+	//
+	// +kubebuilder:default:=false
+	Suspend bool `json:"suspend,omitempty"`
+
+	// DisruptionBudget configures a PodDisruptionBudget that limits how many
+	// pods of the app can be evicted at once, e.g during node drains. Apps
+	// with more than one replica get the operator-wide default budget, if one
+	// is configured, when this is not set.
+	DisruptionBudget *DisruptionBudget `json:"disruptionBudget,omitempty"`
+
 	// RuntimeConfig defines configuration to be applied at runtime for this app.
 	RuntimeConfig RuntimeConfig `json:"runtimeConfig,omitempty"`
 
@@ -66,9 +116,26 @@ type SpinAppSpec struct {
 	// Variables provide Kubernetes Bindings to Spin App Variables.
 	Variables []SpinVar `json:"variables,omitempty"`
 
+	// ListenPort is the port the app listens for HTTP requests on inside its
+	// pods. It is independent of the port the service exposes the app on.
+	// Defaults to the listen port of the executor when the app is created,
+	// or 80 if the executor doesn't set one.
+	//
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=65535
+	ListenPort int32 `json:"listenPort,omitempty"`
+
+	// Service configures the underlying service of the app.
+	Service *ServiceConfig `json:"service,omitempty"`
+
 	// ServiceAnnotations defines annotations to be applied to the underlying service.
 	ServiceAnnotations map[string]string `json:"serviceAnnotations,omitempty"`
 
+	// Exposure configures how the app is exposed outside of the cluster, using
+	// an Ingress and/or a Gateway API HTTPRoute that routes to the underlying
+	// service.
+	Exposure *Exposure `json:"exposure,omitempty"`
+
 	// DeploymentAnnotations defines annotations to be applied to the underlying deployment.
 	DeploymentAnnotations map[string]string `json:"deploymentAnnotations,omitempty"`
 
@@ -81,6 +148,44 @@ type SpinAppSpec struct {
 	// Resources defines the resource requirements for this app.
 	Resources Resources `json:"resources,omitempty"`
 
+	// ServiceAccountName is the name of the ServiceAccount the pods of the app
+	// run as. Defaults to the default ServiceAccount of the namespace.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// AutomountServiceAccountToken indicates whether the ServiceAccount token
+	// is mounted into the pods of the app. Defaults to the setting of the
+	// ServiceAccount.
+	AutomountServiceAccountToken *bool `json:"automountServiceAccountToken,omitempty"`
+
+	// PodSecurityContext defines the security context of the pods of the app.
+	// Fields that are set replace those of the executor defaults.
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+
+	// SecurityContext defines the security context of the app container.
+	// Fields that are set replace those of the executor defaults.
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+
+	// PodScheduling constrains which nodes the pods of the app are scheduled
+	// on. It is merged onto the scheduling defaults of the executor.
+	PodScheduling `json:",inline"`
+
+	// RolloutControls configure how the Deployment of the app rolls out new
+	// versions. Unset fields default to the values of the executor.
+	RolloutControls `json:",inline"`
+
+	// RollbackTo rolls the app back to the SpinAppRevision with this number.
+	// The operator restores the image, executor, runtime config and variables
+	// of the revision and clears this field.
+	//
+	// +kubebuilder:validation:Minimum:=1
+	RollbackTo *int64 `json:"rollbackTo,omitempty"`
+
+	// Rollout enables progressive delivery of image changes. Instead of
+	// replacing every pod at once, a new image is first run by a separate
+	// canary Deployment next to the stable one, and only becomes the stable
+	// image once the rollout completes. Can't be used with autoscaling.
+	Rollout *ProgressiveRollout `json:"rollout,omitempty"`
+
 	// Components of the app to execute.
 	//
 	// If this is not provided all components are executed.
@@ -91,13 +196,16 @@ type SpinAppSpec struct {
 // SpinAppStatus defines the observed state of SpinApp
 type SpinAppStatus struct {
 	// Represents the observations of a SpinApps's current state.
-	// SpinApp.status.conditions.type are: "Available" and "Progressing"
+	// SpinApp.status.conditions.type are: "Ready", "ExecutorResolved", "RuntimeConfigReady", "Available", "Progressing", "RolloutFailed", "Suspended", "Degraded", "ScaledObjectReady" and "HTTPRouteReady"
 	// SpinApp.status.conditions.status are one of True, False, Unknown.
 	// SpinApp.status.conditions.reason the value should be a CamelCase string and producers of specific
 	// condition types may define expected values and meanings for this field, and whether the values
 	// are considered a guaranteed API.
 	// SpinApp.status.conditions.Message is a human readable message indicating details about the transition.
 	// For further information see: https://github.com/kubernetes/community/blob/master/contributors/devel/sig-architecture/api-conventions.md#typical-status-properties
+	//
+	// +listType=map
+	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
 
 	// ActiveScheduler is the name of the scheduler that is currently scheduling this SpinApp.
@@ -105,15 +213,128 @@ type SpinAppStatus struct {
 
 	// Represents the current number of active replicas on the application deployment.
 	ReadyReplicas int32 `json:"readyReplicas"`
+
+	// Replicas is the total number of replicas of the application deployment.
+	Replicas int32 `json:"replicas"`
+
+	// UpdatedReplicas is the number of replicas of the application deployment
+	// that run its current pod template.
+	UpdatedReplicas int32 `json:"updatedReplicas,omitempty"`
+
+	// ObservedGeneration is the generation of the SpinApp that the status
+	// reflects.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Image is the app image the stable deployment of the app runs. It only
+	// differs from spec.image while a progressive rollout is in progress.
+	Image string `json:"image,omitempty"`
+
+	// RuntimeConfigSecretName is the name of the Secret holding the runtime
+	// config generated for the app.
+	RuntimeConfigSecretName string `json:"runtimeConfigSecretName,omitempty"`
+
+	// Selector is the label selector for the pods of the app, in the string
+	// form used by the scale subresource.
+	Selector string `json:"selector,omitempty"`
+
+	// URL is the address the app is exposed at, when an Ingress or HTTPRoute
+	// is configured.
+	URL string `json:"url,omitempty"`
+
+	// Rollout is the state of the progressive rollout of the app, when
+	// spec.rollout is set.
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+
+	// CurrentRevision is the number of the SpinAppRevision that matches the
+	// current configuration of the app.
+	CurrentRevision int64 `json:"currentRevision,omitempty"`
+}
+
+// AutoscalerTarget is the resource an external autoscaler of an app targets.
+type AutoscalerTarget string
+
+const (
+	// AutoscalerTargetDeployment means the autoscaler targets the Deployment
+	// of the app and owns its replica count.
+	AutoscalerTargetDeployment AutoscalerTarget = "Deployment"
+	// AutoscalerTargetSpinApp means the autoscaler targets the SpinApp through
+	// its scale subresource.
+	AutoscalerTargetSpinApp AutoscalerTarget = "SpinApp"
+)
+
+// RolloutPhase is the phase of a progressive rollout.
+type RolloutPhase string
+
+const (
+	// RolloutPhaseStable means the app runs its stable image and no rollout is
+	// in progress.
+	RolloutPhaseStable RolloutPhase = "Stable"
+	// RolloutPhaseProgressing means a new image is being rolled out to the
+	// canary Deployment.
+	RolloutPhaseProgressing RolloutPhase = "Progressing"
+	// RolloutPhasePromoting means the new image is being rolled out to the
+	// stable Deployment, after which the canary Deployment is removed.
+	RolloutPhasePromoting RolloutPhase = "Promoting"
+	// RolloutPhaseAborted means the last rollout was aborted and the app runs
+	// its stable image.
+	RolloutPhaseAborted RolloutPhase = "Aborted"
+)
+
+// RolloutTrafficRouting is how traffic is split between the stable and canary
+// Deployments of an app.
+type RolloutTrafficRouting string
+
+const (
+	// RolloutTrafficRoutingReplicaRatio splits traffic by the ratio of stable
+	// and canary replicas behind the Service of the app.
+	RolloutTrafficRoutingReplicaRatio RolloutTrafficRouting = "ReplicaRatio"
+	// RolloutTrafficRoutingHTTPRoute splits traffic with weighted backends of
+	// the HTTPRoute of the app.
+	RolloutTrafficRoutingHTTPRoute RolloutTrafficRouting = "HTTPRoute"
+)
+
+// RolloutStatus is the observed state of a progressive rollout.
+type RolloutStatus struct {
+	// Phase is the phase of the rollout.
+	Phase RolloutPhase `json:"phase"`
+
+	// StableImage is the image run by the stable Deployment of the app.
+	StableImage string `json:"stableImage"`
+
+	// CanaryImage is the image that is being rolled out.
+	CanaryImage string `json:"canaryImage,omitempty"`
+
+	// CurrentStep is the index of the canary step that is in progress.
+	CurrentStep *int32 `json:"currentStep,omitempty"`
+
+	// StepReadyTime is when every pod of the canary Deployment became ready
+	// in the current step, which starts its pause.
+	StepReadyTime *metav1.Time `json:"stepReadyTime,omitempty"`
+
+	// TrafficRouting is how traffic is split during the rollout.
+	TrafficRouting RolloutTrafficRouting `json:"trafficRouting,omitempty"`
+
+	// AbortedImage is the image of the last rollout that was aborted because
+	// the canary Deployment exceeded its progress deadline. It isn't rolled
+	// out again until spec.image changes.
+	AbortedImage string `json:"abortedImage,omitempty"`
+
+	// Message is a human readable description of the state of the rollout.
+	Message string `json:"message,omitempty"`
 }
 
 // SpinApp is the Schema for the spinapps API
 //
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
 // +kubebuilder:printcolumn:JSONPath=".status.readyReplicas",name=Ready,type=integer
 // +kubebuilder:printcolumn:JSONPath=".spec.replicas",name=Desired,type=integer
 // +kubebuilder:printcolumn:JSONPath=".spec.executor",name=Executor,type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.suspend",name=Suspended,type=boolean,priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.url",name=URL,type=string,priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.rollout.phase",name=Rollout,type=string,priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.currentRevision",name=Revision,type=integer,priority=1
 type SpinApp struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -149,6 +370,14 @@ type RuntimeConfig struct {
 	KeyValueStores []KeyValueStoreConfig `json:"keyValueStores,omitempty"`
 
 	LLMCompute *LLMComputeConfig `json:"llmCompute,omitempty"`
+
+	// VariablesProviders configures providers that Spin resolves application
+	// variables from in addition to the variables of the app, e.g Vault or Azure
+	// Key Vault. Providers are consulted in order, after the variables of the
+	// app.
+	//
+	// +optional
+	VariablesProviders []VariablesProviderConfig `json:"variablesProviders,omitempty"`
 }
 
 type SqliteDatabaseConfig struct {
@@ -163,6 +392,19 @@ type KeyValueStoreConfig struct {
 	Options []RuntimeConfigOption `json:"options,omitempty"`
 }
 
+type VariablesProviderConfig struct {
+	// Type of the variables provider.
+	//
+	// +kubebuilder:validation:Enum=vault;azure_key_vault
+	Type string `json:"type"`
+
+	// Options of the variables provider. Vault providers require "url", "token"
+	// and "mount", and accept "prefix". Azure Key Vault providers require
+	// "vault_url", and accept "client_id", "client_secret", "tenant_id" and
+	// "authority_host".
+	Options []RuntimeConfigOption `json:"options,omitempty"`
+}
+
 type LLMComputeConfig struct {
 	Type    string                `json:"type"`
 	Options []RuntimeConfigOption `json:"options,omitempty"`
@@ -177,7 +419,9 @@ type RuntimeConfigOption struct {
 	// +optional
 	Value string `json:"value,omitempty"`
 
-	// ValueFrom is a reference to dynamically bind the variable to.
+	// ValueFrom is a reference to dynamically bind the variable to. Referencing
+	// a key that doesn't exist is an error unless the selector is optional, in
+	// which case the option is omitted.
 	//
 	// +optional
 	ValueFrom *RuntimeConfigVarSource `json:"valueFrom,omitempty"`
@@ -220,6 +464,400 @@ type Resources struct {
 	Requests corev1.ResourceList `json:"requests,omitempty"`
 }
 
+// Autoscaling defines the HorizontalPodAutoscaler that the operator manages for
+// an app. Utilization targets are relative to the resource requests of the
+// app, so the matching requests must be set in Resources.
+type Autoscaling struct {
+	// MinReplicas is the lower limit for the number of replicas.
+	// Defaults to 1. It can only be 0 when scaling with KEDA, which supports
+	// scaling to zero.
+	//
+	// +kubebuilder:validation:Minimum:=0
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas is the upper limit for the number of replicas. It cannot be
+	// lower than MinReplicas.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum:=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// TargetCPUUtilizationPercentage is the target average CPU utilization of
+	// the app, as a percentage of the requested CPU.
+	//
+	// +kubebuilder:validation:Minimum:=1
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+
+	// TargetMemoryUtilizationPercentage is the target average memory
+	// utilization of the app, as a percentage of the requested memory.
+	//
+	// +kubebuilder:validation:Minimum:=1
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+
+	// ScaleDownStabilizationWindowSeconds is the number of seconds for which
+	// past recommendations are considered when scaling down, which prevents
+	// flapping. Defaults to 300 seconds.
+	//
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=3600
+	ScaleDownStabilizationWindowSeconds *int32 `json:"scaleDownStabilizationWindowSeconds,omitempty"`
+
+	// Behavior configures the scaling policies used when scaling up and down.
+	Behavior *autoscalingv2.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
+
+	// KEDA scales the app with a KEDA ScaledObject instead of a
+	// HorizontalPodAutoscaler. KEDA must be installed in the cluster. The
+	// replica limits, utilization targets and behavior above are applied to the
+	// ScaledObject, which also targets the app through its scale subresource.
+	KEDA *KEDAAutoscaling `json:"keda,omitempty"`
+}
+
+// DisruptionBudget defines the PodDisruptionBudget that the operator manages
+// for an app. Exactly one of MinAvailable and MaxUnavailable must be set, and
+// the budget must allow at least one pod to be evicted.
+type DisruptionBudget struct {
+	// MinAvailable is the number, or percentage, of pods of the app that must
+	// remain available during an eviction. It must be lower than the number
+	// of replicas.
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// MaxUnavailable is the number, or percentage, of pods of the app that can
+	// be unavailable during an eviction. It must be at least 1 (or 1%).
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// KEDAAutoscaling defines the KEDA ScaledObject that the operator manages for
+// an app.
+type KEDAAutoscaling struct {
+	// Triggers activate scaling of the app, e.g a Redis list or a message queue.
+	// See https://keda.sh/docs/latest/scalers for the available triggers.
+	Triggers []KEDATrigger `json:"triggers,omitempty"`
+
+	// PollingInterval is the interval, in seconds, at which each trigger is
+	// checked. Defaults to 30 seconds.
+	//
+	// +kubebuilder:validation:Minimum:=1
+	PollingInterval *int32 `json:"pollingInterval,omitempty"`
+
+	// CooldownPeriod is the number of seconds to wait after the last trigger
+	// was active before scaling to zero. Defaults to 300 seconds.
+	//
+	// +kubebuilder:validation:Minimum:=0
+	CooldownPeriod *int32 `json:"cooldownPeriod,omitempty"`
+}
+
+// KEDATrigger defines a KEDA scaler that activates scaling of an app.
+type KEDATrigger struct {
+	// Type is the type of the scaler, e.g redis or kafka.
+	//
+	// +kubebuilder:validation:Required
+	Type string `json:"type"`
+
+	// Name of the trigger, which can be used to reference it from scaling
+	// modifiers.
+	Name string `json:"name,omitempty"`
+
+	// MetricType is the type of metric used by the trigger, one of
+	// AverageValue, Value or Utilization.
+	//
+	// +kubebuilder:validation:Enum=AverageValue;Value;Utilization
+	MetricType string `json:"metricType,omitempty"`
+
+	// Metadata is the configuration of the scaler.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// AuthenticationRef references a TriggerAuthentication, or a
+	// ClusterTriggerAuthentication, that provides credentials to the scaler.
+	AuthenticationRef *KEDAAuthenticationRef `json:"authenticationRef,omitempty"`
+}
+
+// KEDAAuthenticationRef references a KEDA TriggerAuthentication.
+type KEDAAuthenticationRef struct {
+	// Name of the TriggerAuthentication.
+	//
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Kind is either TriggerAuthentication or ClusterTriggerAuthentication.
+	// Defaults to TriggerAuthentication.
+	//
+	// +kubebuilder:validation:Enum=TriggerAuthentication;ClusterTriggerAuthentication
+	Kind string `json:"kind,omitempty"`
+}
+
+// PodScheduling defines how the pods of an app are scheduled.
+//
+// When set on an executor the values act as defaults for every app using it,
+// which apps merge their own values onto: node selectors are merged with the
+// labels of the app taking precedence, tolerations are combined, node
+// affinity, pod affinity and pod anti-affinity of the app replace those of the
+// executor, and topology spread constraints of the app replace the executor
+// constraint with the same topologyKey and whenUnsatisfiable.
+type PodScheduling struct {
+	// NodeSelector restricts the pods to nodes with these labels.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations allow the pods to be scheduled on nodes with matching
+	// taints, e.g nodes dedicated to running Spin apps.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity defines node affinity and inter-pod (anti-)affinity rules for
+	// the pods.
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// TopologySpreadConstraints control how the pods are spread across
+	// topology domains such as zones. Constraints without a labelSelector
+	// select the pods of the app.
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PriorityClassName is the name of the PriorityClass of the pods.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+}
+
+// ProgressiveRollout configures progressive delivery of image changes.
+// Exactly one of Canary and BlueGreen must be set.
+type ProgressiveRollout struct {
+	// Canary shifts traffic to the new image in steps. Traffic is split by the
+	// ratio of stable and canary replicas, or with a weighted HTTPRoute when
+	// spec.exposure.httpRoute is set and the Gateway API is installed.
+	Canary *CanaryRollout `json:"canary,omitempty"`
+
+	// BlueGreen runs the new image with the full number of replicas next to
+	// the stable image, and switches all traffic to it at once when it is
+	// ready.
+	BlueGreen *BlueGreenRollout `json:"blueGreen,omitempty"`
+
+	// Abort aborts the rollout that is in progress and scales the app back to
+	// its stable image. No new rollout is started while it is set.
+	Abort bool `json:"abort,omitempty"`
+}
+
+// CanaryRollout defines the steps of a canary rollout.
+type CanaryRollout struct {
+	// Steps are the traffic weights the new image goes through before it is
+	// promoted to the stable image.
+	//
+	// +kubebuilder:validation:MinItems:=1
+	Steps []CanaryStep `json:"steps"`
+}
+
+// CanaryStep is a step of a canary rollout.
+type CanaryStep struct {
+	// Weight is the percentage of traffic sent to the new image.
+	//
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=100
+	Weight int32 `json:"weight"`
+
+	// Pause is how long every canary pod must stay ready before the rollout
+	// moves on to the next step. The pause restarts when a canary pod becomes
+	// unready.
+	Pause *metav1.Duration `json:"pause,omitempty"`
+}
+
+// BlueGreenRollout defines a blue/green rollout.
+type BlueGreenRollout struct {
+	// PromotionPause is how long every pod of the new image must stay ready
+	// before traffic is switched to it. The pause restarts when a pod becomes
+	// unready.
+	PromotionPause *metav1.Duration `json:"promotionPause,omitempty"`
+}
+
+// RolloutControls define how the Deployment of an app replaces its pods when
+// the app changes.
+//
+// When set on an executor the values act as defaults for every app using it.
+// Each field set on the app replaces the value of the executor.
+type RolloutControls struct {
+	// Strategy is the strategy used to replace old pods with new ones.
+	// Defaults to a RollingUpdate with 25% maxSurge and maxUnavailable.
+	Strategy *appsv1.DeploymentStrategy `json:"strategy,omitempty"`
+
+	// MinReadySeconds is the minimum number of seconds a new pod has to be
+	// ready for before it counts as available. Defaults to 0.
+	//
+	// +kubebuilder:validation:Minimum:=0
+	MinReadySeconds *int32 `json:"minReadySeconds,omitempty"`
+
+	// ProgressDeadlineSeconds is the number of seconds a rollout can make no
+	// progress for before the `RolloutFailed` condition is set on the app.
+	// Must be greater than MinReadySeconds. Defaults to 600.
+	//
+	// +kubebuilder:validation:Minimum:=1
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+
+	// RevisionHistoryLimit is the number of old ReplicaSets to retain to
+	// allow rollbacks. Defaults to 10.
+	//
+	// +kubebuilder:validation:Minimum:=0
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+}
+
+// ServiceConfig defines the Service that the operator manages for an app.
+type ServiceConfig struct {
+	// Skip disables creation of a Service for the app, e.g for apps that are
+	// only triggered by a message queue and don't serve HTTP requests. A
+	// previously created Service is removed. The app can't be exposed when
+	// this is set.
+	Skip bool `json:"skip,omitempty"`
+
+	// Type of the Service, one of ClusterIP, NodePort or LoadBalancer.
+	// Defaults to ClusterIP.
+	//
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// Headless creates a headless Service, which has no cluster IP and
+	// resolves to the addresses of the pods of the app. It can only be used
+	// with the ClusterIP type and can't be changed once the Service exists.
+	Headless bool `json:"headless,omitempty"`
+
+	// Port is the port the Service serves the app on. Defaults to 80.
+	//
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=65535
+	Port *int32 `json:"port,omitempty"`
+
+	// NodePort is the port the Service is exposed on on every node when the
+	// type is NodePort or LoadBalancer. Defaults to a port allocated by the
+	// cluster.
+	//
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=65535
+	NodePort *int32 `json:"nodePort,omitempty"`
+
+	// ExternalTrafficPolicy determines how traffic from outside the cluster is
+	// routed when the type is NodePort or LoadBalancer, one of Cluster or
+	// Local. Local preserves the client source IP.
+	//
+	// +kubebuilder:validation:Enum=Cluster;Local
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicy `json:"externalTrafficPolicy,omitempty"`
+
+	// SessionAffinity routes requests from the same client to the same pod
+	// when set to ClientIP. Defaults to None.
+	//
+	// +kubebuilder:validation:Enum=None;ClientIP
+	SessionAffinity corev1.ServiceAffinity `json:"sessionAffinity,omitempty"`
+
+	// LoadBalancerSourceRanges restricts the client IP ranges (CIDRs) that can
+	// access a LoadBalancer Service.
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+
+	// IPFamilies are the IP families (IPv4, IPv6) of the Service, in order of
+	// preference.
+	//
+	// +kubebuilder:validation:MaxItems:=2
+	// +kubebuilder:validation:items:Enum=IPv4;IPv6
+	IPFamilies []corev1.IPFamily `json:"ipFamilies,omitempty"`
+
+	// IPFamilyPolicy is the dual-stack policy of the Service, one of
+	// SingleStack, PreferDualStack or RequireDualStack.
+	//
+	// +kubebuilder:validation:Enum=SingleStack;PreferDualStack;RequireDualStack
+	IPFamilyPolicy *corev1.IPFamilyPolicy `json:"ipFamilyPolicy,omitempty"`
+}
+
+// Exposure defines the resources that the operator manages to expose an app
+// outside of the cluster. Both an Ingress and an HTTPRoute can be configured,
+// e.g while migrating to the Gateway API.
+type Exposure struct {
+	// Ingress configures a networking.k8s.io/v1 Ingress for the app.
+	Ingress *IngressExposure `json:"ingress,omitempty"`
+
+	// HTTPRoute configures a Gateway API HTTPRoute for the app. The Gateway
+	// API CRDs must be installed in the cluster.
+	HTTPRoute *HTTPRouteExposure `json:"httpRoute,omitempty"`
+}
+
+// IngressExposure defines the Ingress that the operator manages for an app.
+type IngressExposure struct {
+	// IngressClassName is the name of the IngressClass that implements the
+	// Ingress. Defaults to the default IngressClass of the cluster.
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+
+	// Host is the fully qualified domain name the app is served at. A
+	// wildcard prefix (e.g `*.example.com`) is allowed.
+	//
+	// +kubebuilder:validation:Required
+	Host string `json:"host"`
+
+	// Paths that are routed to the app. Defaults to `/`.
+	Paths []IngressPath `json:"paths,omitempty"`
+
+	// TLSSecretName is the name of a secret containing the TLS certificate for
+	// the host. TLS is not terminated by the Ingress if this is empty.
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+
+	// Annotations defines annotations to be applied to the Ingress, e.g to
+	// configure the ingress controller.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// IngressPath defines a path that is routed to an app by an Ingress.
+type IngressPath struct {
+	// Path is matched against the path of incoming requests. It must begin
+	// with `/`.
+	//
+	// +kubebuilder:validation:Required
+	Path string `json:"path"`
+
+	// PathType determines how the path is matched, one of Prefix, Exact or
+	// ImplementationSpecific. Defaults to Prefix.
+	//
+	// +kubebuilder:validation:Enum=Prefix;Exact;ImplementationSpecific
+	PathType *networkingv1.PathType `json:"pathType,omitempty"`
+}
+
+// HTTPRouteExposure defines the Gateway API HTTPRoute that the operator
+// manages for an app.
+type HTTPRouteExposure struct {
+	// ParentRefs are the Gateways that the route attaches to.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems:=1
+	ParentRefs []GatewayParentRef `json:"parentRefs"`
+
+	// Hostnames are matched against the Host header of incoming requests.
+	// Defaults to the hostnames of the listeners of the parent Gateways.
+	Hostnames []string `json:"hostnames,omitempty"`
+
+	// Paths that are routed to the app. Defaults to `/`.
+	Paths []HTTPRoutePath `json:"paths,omitempty"`
+
+	// Annotations defines annotations to be applied to the HTTPRoute.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// GatewayParentRef references a Gateway that an HTTPRoute attaches to.
+type GatewayParentRef struct {
+	// Name of the Gateway.
+	//
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Namespace of the Gateway. Defaults to the namespace of the app.
+	Namespace string `json:"namespace,omitempty"`
+
+	// SectionName is the name of the listener of the Gateway to attach to.
+	// Defaults to all listeners of the Gateway.
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// HTTPRoutePath defines a path that is routed to an app by an HTTPRoute.
+type HTTPRoutePath struct {
+	// Value is matched against the path of incoming requests. It must begin
+	// with `/`.
+	//
+	// +kubebuilder:validation:Required
+	Value string `json:"value"`
+
+	// Type determines how the path is matched, one of PathPrefix, Exact or
+	// RegularExpression. Defaults to PathPrefix.
+	//
+	// +kubebuilder:validation:Enum=PathPrefix;Exact;RegularExpression
+	Type string `json:"type,omitempty"`
+}
+
 // HealthChecks defines configuration for readiness and liveness probes for the
 // application.
 type HealthChecks struct {
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -58,12 +59,55 @@ type ExecutorDeploymentConfig struct {
 
 	// Otel provides Kubernetes Bindings to Otel Variables.
 	Otel *OtelConfig `json:"otel,omitempty"`
+
+	// ListenPort is the default port that apps using this executor listen for
+	// HTTP requests on inside their pods. Apps can override it. Defaults to 80,
+	// which may require privileged-port sysctls in hardened clusters.
+	//
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=65535
+	ListenPort *int32 `json:"listenPort,omitempty"`
+
+	// PodSecurityContext defines the default security context of the pods of
+	// apps using this executor, e.g `runAsNonRoot` and a `RuntimeDefault`
+	// seccomp profile to satisfy the `restricted` Pod Security Standard. Apps
+	// can override individual fields, which is reported as a warning when it
+	// weakens these defaults.
+	//
+	// The operator doesn't set a security context of its own, as it can't tell
+	// whether the images of an executor run as a non-root user. Executors opt
+	// in to the restricted settings, as the restricted-spintainer sample does.
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+
+	// SecurityContext defines the default security context of the containers
+	// of apps using this executor, e.g dropping `ALL` capabilities and
+	// disallowing privilege escalation. Apps can override individual fields,
+	// which is reported as a warning when it weakens these defaults.
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+
+	// PodScheduling defines scheduling defaults for the pods of apps using
+	// this executor, e.g tolerating the taint of nodes that run the Spin shim.
+	// Apps merge their own scheduling configuration onto these defaults.
+	PodScheduling `json:",inline"`
+
+	// RolloutControls define the default rollout behaviour of the Deployments
+	// of apps using this executor, e.g a longer progress deadline for large
+	// apps. Apps can override individual fields.
+	RolloutControls `json:",inline"`
 }
 
 // SpinAppExecutorStatus defines the observed state of SpinAppExecutor
 type SpinAppExecutorStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// AppCount is the number of SpinApps that use this executor.
+	AppCount int32 `json:"appCount"`
+
+	// Conditions represent the latest available observations of the executor.
+	//
+	// The `Ready` condition reports whether apps can be scheduled with this
+	// executor. `RuntimeClassFound` and `CACertSecretFound` report whether the
+	// RuntimeClass and CA certificate secret referenced by the deployment config
+	// exist, and are only set when the executor references them.
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
 }
 
 // OtelConfig is the supported environment variables for OpenTelemetry
@@ -80,6 +124,10 @@ type OtelConfig struct {
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:JSONPath=".status.conditions[?(@.type==\"Ready\")].status",name=Ready,type=string
+//+kubebuilder:printcolumn:JSONPath=".status.appCount",name=Apps,type=integer
+//+kubebuilder:printcolumn:JSONPath=".spec.deploymentConfig.runtimeClassName",name=RuntimeClass,type=string
+//+kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name=Age,type=date
 
 // SpinAppExecutor is the Schema for the spinappexecutors API
 type SpinAppExecutor struct {
@@ -0,0 +1,80 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SpinAppRevisionSpec is a snapshot of the effective configuration of a
+// SpinApp.
+type SpinAppRevisionSpec struct {
+	// Image is the image of the app.
+	Image string `json:"image"`
+
+	// Executor is the name of the executor of the app.
+	Executor string `json:"executor"`
+
+	// RuntimeConfigSecretName is the name of the runtime config secret that
+	// was generated for the app, if any.
+	RuntimeConfigSecretName string `json:"runtimeConfigSecretName,omitempty"`
+
+	// RuntimeConfig is the runtime configuration of the app.
+	RuntimeConfig RuntimeConfig `json:"runtimeConfig,omitempty"`
+
+	// Variables are the variables of the app.
+	Variables []SpinVar `json:"variables,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:printcolumn:JSONPath=".metadata.labels.core\\.spinkube\\.dev/app-name",name=App,type=string
+//+kubebuilder:printcolumn:JSONPath=".revision",name=Revision,type=integer
+//+kubebuilder:printcolumn:JSONPath=".spec.image",name=Image,type=string
+//+kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name=Age,type=date
+
+// SpinAppRevision is an immutable snapshot of the configuration a SpinApp ran
+// with. The operator records a revision whenever the configuration of an app
+// changes, and an app can be rolled back to a revision by setting
+// spec.rollbackTo to its number.
+type SpinAppRevision struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Revision is the number of the revision. It increases with every change
+	// to the configuration of the app.
+	//
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="revision is immutable"
+	Revision int64 `json:"revision"`
+
+	// Spec is the configuration of the app at this revision.
+	//
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+	Spec SpinAppRevisionSpec `json:"spec"`
+}
+
+//+kubebuilder:object:root=true
+
+// SpinAppRevisionList contains a list of SpinAppRevision
+type SpinAppRevisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SpinAppRevision `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SpinAppRevision{}, &SpinAppRevisionList{})
+}
@@ -19,6 +19,7 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -27,10 +28,14 @@ import (
 
 	"github.com/prometheus/common/version"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -62,6 +67,9 @@ func main() {
 	var enableWebhooks bool
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var runtimeConfigHistoryLimit int
+	var revisionHistoryLimit int
+	var defaultPDBMaxUnavailable string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8082", "The address the probe endpoint binds to.")
@@ -73,6 +81,13 @@ func main() {
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics server")
+	flag.IntVar(&runtimeConfigHistoryLimit, "runtime-config-history-limit", 2,
+		"The number of previous generated runtime config secrets to retain per app for rollbacks.")
+	flag.IntVar(&revisionHistoryLimit, "spinapp-revision-history-limit", 10,
+		"The number of SpinAppRevisions to retain per app, including the current one.")
+	flag.StringVar(&defaultPDBMaxUnavailable, "default-pdb-max-unavailable", "",
+		"If set, apps with more than one replica and no disruption budget get a PodDisruptionBudget "+
+			"allowing this number (e.g. 1) or percentage (e.g. 25%) of their pods to be unavailable.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -130,6 +145,12 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "90ba2d18.spinkube.dev",
+		Cache: cache.Options{
+			// Pods are only watched to surface failures of SpinApps
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.Pod{}: {Label: controller.SpinAppPodSelector()},
+			},
+		},
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -147,10 +168,20 @@ func main() {
 		os.Exit(1)
 	}
 
+	defaultDisruptionBudget, err := parseDefaultDisruptionBudget(defaultPDBMaxUnavailable)
+	if err != nil {
+		setupLog.Error(err, "invalid --default-pdb-max-unavailable")
+		os.Exit(1)
+	}
+
 	if err = (&controller.SpinAppReconciler{
 		Client:   mgr.GetClient(),
 		Scheme:   mgr.GetScheme(),
 		Recorder: mgr.GetEventRecorderFor("spinapp-reconciler"),
+
+		RuntimeConfigHistoryLimit:   runtimeConfigHistoryLimit,
+		SpinAppRevisionHistoryLimit: revisionHistoryLimit,
+		DefaultDisruptionBudget:     defaultDisruptionBudget,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "SpinApp")
 		os.Exit(1)
@@ -163,6 +194,14 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "SpinAppExecutor")
 		os.Exit(1)
 	}
+	if err = (&controller.ClusterSpinAppExecutorReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("clusterspinappexecutor-reconciler"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterSpinAppExecutor")
+		os.Exit(1)
+	}
 	if enableWebhooks {
 		if err = webhook.SetupSpinAppWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "SpinApp")
@@ -172,6 +211,10 @@ func main() {
 			setupLog.Error(err, "unable to create webhook", "webhook", "SpinAppExecutor")
 			os.Exit(1)
 		}
+		if err = webhook.SetupClusterSpinAppExecutorWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ClusterSpinAppExecutor")
+			os.Exit(1)
+		}
 	}
 	//+kubebuilder:scaffold:builder
 
@@ -191,3 +234,23 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// parseDefaultDisruptionBudget parses the operator-wide default disruption
+// budget. An empty value disables the default.
+func parseDefaultDisruptionBudget(maxUnavailable string) (*spinv1alpha1.DisruptionBudget, error) {
+	if maxUnavailable == "" {
+		return nil, nil
+	}
+
+	value := intstr.Parse(maxUnavailable)
+	// Percentages are rounded up, so any positive one allows an eviction.
+	scaled, err := intstr.GetScaledValueFromIntOrPercent(&value, 100, true)
+	if err != nil {
+		return nil, err
+	}
+	if scaled <= 0 {
+		return nil, fmt.Errorf("%q must allow at least one pod to be unavailable", maxUnavailable)
+	}
+
+	return &spinv1alpha1.DisruptionBudget{MaxUnavailable: &value}, nil
+}
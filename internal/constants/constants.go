@@ -20,3 +20,8 @@ const (
 	ContainerDShimSpinExecutor = "containerd-shim-spin"
 	CyclotronExecutor          = "cyclotron"
 )
+
+// DefaultExecutorAnnotationKey is the annotation used to mark a SpinAppExecutor
+// or ClusterSpinAppExecutor as the default executor for SpinApps that don't
+// specify one. The annotation must be set to "true".
+const DefaultExecutorAnnotationKey = OperatorResourceKeyspace + "/is-default-executor"
@@ -0,0 +1,276 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/generics"
+)
+
+// scaleTargetRef references a SpinApp as the target of an autoscaler. The
+// autoscaler sets spec.replicas through the scale subresource of the app, which
+// the operator then propagates to the deployment.
+func scaleTargetRef(app *spinv1alpha1.SpinApp) autoscalingv2.CrossVersionObjectReference {
+	return autoscalingv2.CrossVersionObjectReference{
+		APIVersion: spinv1alpha1.GroupVersion.String(),
+		Kind:       "SpinApp",
+		Name:       app.Name,
+	}
+}
+
+// autoscalingMinReplicas returns the minimum replica count of an autoscaling
+// configuration, which defaults to one.
+func autoscalingMinReplicas(config *spinv1alpha1.Autoscaling) int32 {
+	if config.MinReplicas == nil {
+		return 1
+	}
+	return *config.MinReplicas
+}
+
+// managedAutoscalerTargetsApp returns whether the operator manages an
+// autoscaler for a SpinApp, which targets the app through its scale
+// subresource.
+func managedAutoscalerTargetsApp(app *spinv1alpha1.SpinApp) bool {
+	return app.Spec.EnableAutoscaling && app.Spec.Autoscaling != nil
+}
+
+// initializeAutoscaledReplicas sets spec.replicas of a SpinApp with a managed
+// autoscaler to the minimum replica count through the scale subresource.
+// Replicas can't be set when autoscaling is enabled, and an HPA doesn't scale
+// targets that have zero replicas, while KEDA only scales from zero when its
+// minimum replica count is zero.
+func (r *SpinAppReconciler) initializeAutoscaledReplicas(ctx context.Context, app *spinv1alpha1.SpinApp) error {
+	minReplicas := autoscalingMinReplicas(app.Spec.Autoscaling)
+	if app.Spec.Replicas != 0 || minReplicas == 0 {
+		return nil
+	}
+
+	scale := &autoscalingv1.Scale{
+		ObjectMeta: metav1.ObjectMeta{Name: app.Name, Namespace: app.Namespace},
+		Spec:       autoscalingv1.ScaleSpec{Replicas: minReplicas},
+	}
+	if err := r.Client.SubResource("scale").Update(ctx, app,
+		client.WithSubResourceBody(scale), client.FieldOwner(FieldManager)); err != nil {
+		return fmt.Errorf("failed to initialize replicas: %w", err)
+	}
+
+	app.Spec.Replicas = minReplicas
+	return nil
+}
+
+// constructHorizontalPodAutoscaler builds an autoscalingv2.HorizontalPodAutoscaler
+// based on the autoscaling configuration of a SpinApp. The autoscaler targets
+// the app through its scale subresource.
+func constructHorizontalPodAutoscaler(app *spinv1alpha1.SpinApp) *autoscalingv2.HorizontalPodAutoscaler {
+	config := app.Spec.Autoscaling
+
+	var metrics []autoscalingv2.MetricSpec
+	if config.TargetCPUUtilizationPercentage != nil {
+		metrics = append(metrics, resourceUtilizationMetric(corev1.ResourceCPU, *config.TargetCPUUtilizationPercentage))
+	}
+	if config.TargetMemoryUtilizationPercentage != nil {
+		metrics = append(metrics, resourceUtilizationMetric(corev1.ResourceMemory, *config.TargetMemoryUtilizationPercentage))
+	}
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "HorizontalPodAutoscaler",
+			APIVersion: "autoscaling/v2",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      app.Name,
+			Namespace: app.Namespace,
+			Labels:    constructAppLabels(app),
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: scaleTargetRef(app),
+			MinReplicas:    generics.Ptr(autoscalingMinReplicas(config)),
+			MaxReplicas:    config.MaxReplicas,
+			Metrics:        metrics,
+			Behavior:       autoscalingBehavior(config),
+		},
+	}
+}
+
+func resourceUtilizationMetric(name corev1.ResourceName, averageUtilization int32) autoscalingv2.MetricSpec {
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name: name,
+			Target: autoscalingv2.MetricTarget{
+				Type:               autoscalingv2.UtilizationMetricType,
+				AverageUtilization: generics.Ptr(averageUtilization),
+			},
+		},
+	}
+}
+
+// autoscalingBehavior returns the scaling behavior for an autoscaling
+// configuration, with the scale down stabilization window merged in.
+func autoscalingBehavior(config *spinv1alpha1.Autoscaling) *autoscalingv2.HorizontalPodAutoscalerBehavior {
+	behavior := config.Behavior.DeepCopy()
+	if config.ScaleDownStabilizationWindowSeconds != nil {
+		if behavior == nil {
+			behavior = &autoscalingv2.HorizontalPodAutoscalerBehavior{}
+		}
+		if behavior.ScaleDown == nil {
+			behavior.ScaleDown = &autoscalingv2.HPAScalingRules{}
+		}
+		behavior.ScaleDown.StabilizationWindowSeconds = config.ScaleDownStabilizationWindowSeconds
+	}
+	return behavior
+}
+
+// spinAppConditionScaledObjectReady reports whether the KEDA ScaledObject of a
+// SpinApp that uses KEDA autoscaling could be applied.
+const spinAppConditionScaledObjectReady = "ScaledObjectReady"
+
+// scaledObjectReadyCondition derives the ScaledObjectReady condition of a
+// SpinApp whose ScaledObject was reconciled. The warning event for a missing
+// KEDA installation is emitted when the condition changes, rather than on
+// every reconcile while KEDA isn't installed.
+func scaledObjectReadyCondition(kedaInstalled bool) metav1.Condition {
+	if !kedaInstalled {
+		return metav1.Condition{
+			Type:    spinAppConditionScaledObjectReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  "KEDANotInstalled",
+			Message: "spec.autoscaling.keda is set but the keda.sh/v1alpha1 ScaledObject CRD is not installed, the app will not be autoscaled",
+		}
+	}
+
+	return metav1.Condition{
+		Type:    spinAppConditionScaledObjectReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ScaledObjectApplied",
+		Message: "Applied the KEDA ScaledObject",
+	}
+}
+
+// errKEDANotInstalled is returned when an app uses KEDA autoscaling but KEDA
+// isn't installed in the cluster.
+var errKEDANotInstalled = errors.New("KEDA is not installed")
+
+// scaledObjectGVK is the KEDA ScaledObject kind. KEDA is an optional
+// dependency, so ScaledObjects are handled as unstructured objects.
+var scaledObjectGVK = schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledObject"}
+
+// constructScaledObject builds a KEDA ScaledObject based on the autoscaling
+// configuration of a SpinApp. Like the HorizontalPodAutoscaler, it targets the
+// app through its scale subresource. Utilization targets are rendered as cpu
+// and memory triggers.
+func constructScaledObject(app *spinv1alpha1.SpinApp) (*unstructured.Unstructured, error) {
+	config := app.Spec.Autoscaling
+	keda := config.KEDA
+
+	var triggers []interface{}
+	if config.TargetCPUUtilizationPercentage != nil {
+		triggers = append(triggers, utilizationTrigger(corev1.ResourceCPU, *config.TargetCPUUtilizationPercentage))
+	}
+	if config.TargetMemoryUtilizationPercentage != nil {
+		triggers = append(triggers, utilizationTrigger(corev1.ResourceMemory, *config.TargetMemoryUtilizationPercentage))
+	}
+	for _, trigger := range keda.Triggers {
+		rendered := map[string]interface{}{
+			"type":     trigger.Type,
+			"metadata": stringMapToUnstructured(trigger.Metadata),
+		}
+		if trigger.Name != "" {
+			rendered["name"] = trigger.Name
+		}
+		if trigger.MetricType != "" {
+			rendered["metricType"] = trigger.MetricType
+		}
+		if ref := trigger.AuthenticationRef; ref != nil {
+			authRef := map[string]interface{}{"name": ref.Name}
+			if ref.Kind != "" {
+				authRef["kind"] = ref.Kind
+			}
+			rendered["authenticationRef"] = authRef
+		}
+		triggers = append(triggers, rendered)
+	}
+
+	targetRef := scaleTargetRef(app)
+	spec := map[string]interface{}{
+		"scaleTargetRef": map[string]interface{}{
+			"apiVersion": targetRef.APIVersion,
+			"kind":       targetRef.Kind,
+			"name":       targetRef.Name,
+		},
+		"minReplicaCount": int64(autoscalingMinReplicas(config)),
+		"maxReplicaCount": int64(config.MaxReplicas),
+		"triggers":        triggers,
+	}
+	if keda.PollingInterval != nil {
+		spec["pollingInterval"] = int64(*keda.PollingInterval)
+	}
+	if keda.CooldownPeriod != nil {
+		spec["cooldownPeriod"] = int64(*keda.CooldownPeriod)
+	}
+	if behavior := autoscalingBehavior(config); behavior != nil {
+		renderedBehavior, err := runtime.DefaultUnstructuredConverter.ToUnstructured(behavior)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert scaling behavior: %w", err)
+		}
+		spec["advanced"] = map[string]interface{}{
+			"horizontalPodAutoscalerConfig": map[string]interface{}{
+				"behavior": renderedBehavior,
+			},
+		}
+	}
+
+	scaledObject := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	scaledObject.SetGroupVersionKind(scaledObjectGVK)
+	scaledObject.SetName(app.Name)
+	scaledObject.SetNamespace(app.Namespace)
+	scaledObject.SetLabels(constructAppLabels(app))
+
+	return scaledObject, nil
+}
+
+func utilizationTrigger(name corev1.ResourceName, averageUtilization int32) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       string(name),
+		"metricType": string(autoscalingv2.UtilizationMetricType),
+		"metadata": map[string]interface{}{
+			"value": strconv.Itoa(int(averageUtilization)),
+		},
+	}
+}
+
+func stringMapToUnstructured(m map[string]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
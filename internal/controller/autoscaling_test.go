@@ -0,0 +1,358 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/generics"
+)
+
+func TestConstructHorizontalPodAutoscaler(t *testing.T) {
+	t.Parallel()
+
+	app := minimalSpinApp()
+	app.Spec.Replicas = 0
+	app.Spec.EnableAutoscaling = true
+	app.Spec.Autoscaling = &spinv1alpha1.Autoscaling{
+		MaxReplicas:                       5,
+		TargetCPUUtilizationPercentage:    generics.Ptr(int32(50)),
+		TargetMemoryUtilizationPercentage: generics.Ptr(int32(80)),
+	}
+
+	hpa := constructHorizontalPodAutoscaler(app)
+	require.Equal(t, app.Name, hpa.Name)
+	require.Equal(t, app.Namespace, hpa.Namespace)
+	require.Equal(t, autoscalingv2.CrossVersionObjectReference{
+		APIVersion: "core.spinkube.dev/v1alpha1",
+		Kind:       "SpinApp",
+		Name:       app.Name,
+	}, hpa.Spec.ScaleTargetRef)
+	require.Equal(t, generics.Ptr(int32(1)), hpa.Spec.MinReplicas)
+	require.Equal(t, int32(5), hpa.Spec.MaxReplicas)
+	require.Len(t, hpa.Spec.Metrics, 2)
+	require.Equal(t, corev1.ResourceCPU, hpa.Spec.Metrics[0].Resource.Name)
+	require.Equal(t, generics.Ptr(int32(50)), hpa.Spec.Metrics[0].Resource.Target.AverageUtilization)
+	require.Equal(t, corev1.ResourceMemory, hpa.Spec.Metrics[1].Resource.Name)
+	require.Equal(t, generics.Ptr(int32(80)), hpa.Spec.Metrics[1].Resource.Target.AverageUtilization)
+	require.Nil(t, hpa.Spec.Behavior)
+
+	// The scale down stabilization window is merged into the behavior
+	app.Spec.Autoscaling.MinReplicas = generics.Ptr(int32(2))
+	app.Spec.Autoscaling.ScaleDownStabilizationWindowSeconds = generics.Ptr(int32(60))
+	app.Spec.Autoscaling.Behavior = &autoscalingv2.HorizontalPodAutoscalerBehavior{
+		ScaleDown: &autoscalingv2.HPAScalingRules{
+			Policies: []autoscalingv2.HPAScalingPolicy{{
+				Type:          autoscalingv2.PodsScalingPolicy,
+				Value:         1,
+				PeriodSeconds: 30,
+			}},
+		},
+	}
+
+	hpa = constructHorizontalPodAutoscaler(app)
+	require.Equal(t, generics.Ptr(int32(2)), hpa.Spec.MinReplicas)
+	require.Equal(t, generics.Ptr(int32(60)), hpa.Spec.Behavior.ScaleDown.StabilizationWindowSeconds)
+	require.Len(t, hpa.Spec.Behavior.ScaleDown.Policies, 1)
+	// The app itself isn't modified
+	require.Nil(t, app.Spec.Autoscaling.Behavior.ScaleDown.StabilizationWindowSeconds)
+}
+
+func TestInitializeAutoscaledReplicas(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	// The fake client doesn't support the scale subresource of custom
+	// resources, so the update is captured instead.
+	var scales []*autoscalingv1.Scale
+	var updateOptions []client.SubResourceUpdateOption
+	r := &SpinAppReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).
+			WithInterceptorFuncs(interceptor.Funcs{
+				SubResourceUpdate: func(_ context.Context, _ client.Client, subResource string, _ client.Object,
+					opts ...client.SubResourceUpdateOption) error {
+					require.Equal(t, "scale", subResource)
+					options := &client.SubResourceUpdateOptions{}
+					options.ApplyOptions(opts)
+					scales = append(scales, options.SubResourceBody.(*autoscalingv1.Scale))
+					updateOptions = opts
+					return nil
+				},
+			}).Build(),
+		Scheme: scheme,
+	}
+
+	app := minimalSpinApp()
+	app.Spec.Replicas = 0
+	app.Spec.EnableAutoscaling = true
+	app.Spec.Autoscaling = &spinv1alpha1.Autoscaling{MinReplicas: generics.Ptr(int32(2)), MaxReplicas: 5}
+
+	// Replicas start at the minimum of the autoscaler
+	ctx := context.Background()
+	require.NoError(t, r.initializeAutoscaledReplicas(ctx, app))
+	require.Len(t, scales, 1)
+	require.Equal(t, app.Name, scales[0].Name)
+	require.Equal(t, int32(2), scales[0].Spec.Replicas)
+	require.Equal(t, int32(2), app.Spec.Replicas)
+	options := &client.SubResourceUpdateOptions{}
+	options.ApplyOptions(updateOptions)
+	require.Equal(t, FieldManager, options.FieldManager)
+
+	// Replicas set by the autoscaler are left alone
+	app.Spec.Replicas = 4
+	require.NoError(t, r.initializeAutoscaledReplicas(ctx, app))
+	require.Len(t, scales, 1)
+
+	// KEDA scales apps from zero itself
+	keda := minimalSpinApp()
+	keda.Spec.Replicas = 0
+	keda.Spec.EnableAutoscaling = true
+	keda.Spec.Autoscaling = &spinv1alpha1.Autoscaling{
+		MinReplicas: generics.Ptr(int32(0)),
+		MaxReplicas: 5,
+		KEDA:        &spinv1alpha1.KEDAAutoscaling{},
+	}
+	require.NoError(t, r.initializeAutoscaledReplicas(ctx, keda))
+	require.Len(t, scales, 1)
+	require.True(t, managedAutoscalerTargetsApp(keda))
+
+	// The deployment runs the replica count set through the app
+	deployment, err := constructDeployment(ctx, app, &spinv1alpha1.ExecutorDeploymentConfig{
+		RuntimeClassName: generics.Ptr("bananarama"),
+	}, "", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, generics.Ptr(int32(4)), deployment.Spec.Replicas)
+}
+
+func TestDeleteHorizontalPodAutoscaler(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	app := minimalSpinApp()
+	app.UID = "my-app-uid"
+
+	owned := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: app.Name, Namespace: app.Namespace},
+	}
+	require.NoError(t, controllerutil.SetControllerReference(app, owned, scheme))
+
+	// A user managed HPA that happens to have the same name as another app
+	other := minimalSpinApp()
+	other.Name = "other-app"
+	unowned := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: other.Name, Namespace: other.Namespace},
+	}
+
+	r := &SpinAppReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(owned, unowned).Build(),
+		Scheme: scheme,
+	}
+
+	ctx := context.Background()
+	require.NoError(t, r.deleteHorizontalPodAutoscaler(ctx, app))
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(owned), &autoscalingv2.HorizontalPodAutoscaler{})
+	require.True(t, apierrors.IsNotFound(err))
+
+	// Deleting again is a no-op
+	require.NoError(t, r.deleteHorizontalPodAutoscaler(ctx, app))
+
+	require.NoError(t, r.deleteHorizontalPodAutoscaler(ctx, other))
+	require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: other.Name, Namespace: other.Namespace}, &autoscalingv2.HorizontalPodAutoscaler{}))
+}
+
+func TestConstructScaledObject(t *testing.T) {
+	t.Parallel()
+
+	app := minimalSpinApp()
+	app.Spec.Replicas = 0
+	app.Spec.EnableAutoscaling = true
+	app.Spec.Autoscaling = &spinv1alpha1.Autoscaling{
+		MinReplicas:                         generics.Ptr(int32(0)),
+		MaxReplicas:                         20,
+		TargetCPUUtilizationPercentage:      generics.Ptr(int32(50)),
+		ScaleDownStabilizationWindowSeconds: generics.Ptr(int32(60)),
+		KEDA: &spinv1alpha1.KEDAAutoscaling{
+			CooldownPeriod: generics.Ptr(int32(120)),
+			Triggers: []spinv1alpha1.KEDATrigger{{
+				Type:     "redis",
+				Metadata: map[string]string{"listName": "orders", "listLength": "5"},
+				AuthenticationRef: &spinv1alpha1.KEDAAuthenticationRef{
+					Name: "redis-auth",
+				},
+			}},
+		},
+	}
+
+	scaledObject, err := constructScaledObject(app)
+	require.NoError(t, err)
+	require.Equal(t, scaledObjectGVK, scaledObject.GroupVersionKind())
+	require.Equal(t, app.Name, scaledObject.GetName())
+	require.Equal(t, app.Namespace, scaledObject.GetNamespace())
+
+	spec := scaledObject.Object["spec"].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{
+		"apiVersion": "core.spinkube.dev/v1alpha1",
+		"kind":       "SpinApp",
+		"name":       app.Name,
+	}, spec["scaleTargetRef"])
+	require.Equal(t, int64(0), spec["minReplicaCount"])
+	require.Equal(t, int64(20), spec["maxReplicaCount"])
+	require.Equal(t, int64(120), spec["cooldownPeriod"])
+	require.NotContains(t, spec, "pollingInterval")
+	require.Equal(t, []interface{}{
+		map[string]interface{}{
+			"type":       "cpu",
+			"metricType": "Utilization",
+			"metadata":   map[string]interface{}{"value": "50"},
+		},
+		map[string]interface{}{
+			"type":              "redis",
+			"metadata":          map[string]interface{}{"listName": "orders", "listLength": "5"},
+			"authenticationRef": map[string]interface{}{"name": "redis-auth"},
+		},
+	}, spec["triggers"])
+
+	stabilizationWindow, found, err := unstructured.NestedInt64(spec,
+		"advanced", "horizontalPodAutoscalerConfig", "behavior", "scaleDown", "stabilizationWindowSeconds")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, int64(60), stabilizationWindow)
+}
+
+func TestReconcileScaledObject_KEDANotInstalled(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	recorder := record.NewFakeRecorder(1)
+	r := &SpinAppReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Scheme:   scheme,
+		Recorder: recorder,
+	}
+
+	executor := &spinv1alpha1.SpinAppExecutor{Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true}}
+	app := minimalSpinApp()
+	app.Spec.EnableAutoscaling = true
+	app.Spec.Autoscaling = &spinv1alpha1.Autoscaling{MaxReplicas: 3}
+
+	ctx := context.Background()
+
+	// Nothing to clean up when KEDA isn't installed
+	require.NoError(t, r.reconcileScaledObject(ctx, app, executor))
+	require.Empty(t, recorder.Events)
+
+	// The missing installation is reported through the status of the app
+	app.Spec.Autoscaling.KEDA = &spinv1alpha1.KEDAAutoscaling{}
+	err := r.reconcileScaledObject(ctx, app, executor)
+	require.ErrorIs(t, err, errKEDANotInstalled)
+	require.Empty(t, recorder.Events)
+}
+
+func TestScaledObjectReadyCondition(t *testing.T) {
+	t.Parallel()
+
+	recorder := record.NewFakeRecorder(2)
+	r := &SpinAppReconciler{Recorder: recorder}
+
+	executor := &spinv1alpha1.SpinAppExecutor{Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true}}
+	app := minimalSpinApp()
+	app.Spec.EnableAutoscaling = true
+	app.Spec.Autoscaling = &spinv1alpha1.Autoscaling{
+		MaxReplicas: 3,
+		KEDA:        &spinv1alpha1.KEDAAutoscaling{},
+	}
+
+	observed := &statusObservation{Executor: executor, KEDAInstalled: generics.Ptr(false)}
+	status := computeStatus(app, observed)
+	condition := meta.FindStatusCondition(status.Conditions, spinAppConditionScaledObjectReady)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, "KEDANotInstalled", condition.Reason)
+
+	// The warning is only emitted when KEDA is first found missing, not on
+	// every reconcile that checks back whether it was installed
+	r.recordStatusEvents(app, &app.Status, &status)
+	require.Contains(t, <-recorder.Events, "KEDANotInstalled")
+	app.Status = status
+	status = computeStatus(app, observed)
+	r.recordStatusEvents(app, &app.Status, &status)
+	require.Empty(t, recorder.Events)
+
+	// Installing KEDA clears the condition
+	app.Status = status
+	status = computeStatus(app, &statusObservation{Executor: executor, KEDAInstalled: generics.Ptr(true)})
+	require.True(t, meta.IsStatusConditionTrue(status.Conditions, spinAppConditionScaledObjectReady))
+	r.recordStatusEvents(app, &app.Status, &status)
+	require.Empty(t, recorder.Events)
+
+	// The condition is kept as is when the ScaledObject wasn't reconciled, and
+	// removed when the app stops using KEDA
+	app.Status = status
+	status = computeStatus(app, &statusObservation{Executor: executor})
+	require.True(t, meta.IsStatusConditionTrue(status.Conditions, spinAppConditionScaledObjectReady))
+	app.Spec.Autoscaling.KEDA = nil
+	status = computeStatus(app, &statusObservation{Executor: executor})
+	require.Nil(t, meta.FindStatusCondition(status.Conditions, spinAppConditionScaledObjectReady))
+}
+
+func TestDeleteScaledObject(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	restMapper := meta.NewDefaultRESTMapper(nil)
+	restMapper.Add(scaledObjectGVK, meta.RESTScopeNamespace)
+
+	app := minimalSpinApp()
+	app.UID = "my-app-uid"
+	app.Spec.EnableAutoscaling = true
+	app.Spec.Autoscaling = &spinv1alpha1.Autoscaling{MaxReplicas: 3, KEDA: &spinv1alpha1.KEDAAutoscaling{}}
+
+	scaledObject, err := constructScaledObject(app)
+	require.NoError(t, err)
+	require.NoError(t, controllerutil.SetControllerReference(app, scaledObject, scheme))
+
+	r := &SpinAppReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(restMapper).WithObjects(scaledObject).Build(),
+		Scheme: scheme,
+	}
+
+	installed, err := r.isKindInstalled(scaledObjectGVK)
+	require.NoError(t, err)
+	require.True(t, installed)
+
+	ctx := context.Background()
+	require.NoError(t, r.deleteScaledObject(ctx, app))
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(scaledObjectGVK)
+	err = r.Client.Get(ctx, client.ObjectKeyFromObject(scaledObject), existing)
+	require.True(t, apierrors.IsNotFound(err))
+}
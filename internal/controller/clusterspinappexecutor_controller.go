@@ -0,0 +1,278 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/logging"
+)
+
+// ClusterSpinAppExecutorReconciler reconciles a ClusterSpinAppExecutor object
+type ClusterSpinAppExecutorReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=core.spinkube.dev,resources=clusterspinappexecutors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core.spinkube.dev,resources=clusterspinappexecutors/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=core.spinkube.dev,resources=clusterspinappexecutors/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=node.k8s.io,resources=runtimeclasses,verbs=get;list;watch
+
+// SetupWithManager sets up the controller with the Manager. SpinApps are listed
+// through the spinAppExecutorKey index registered by the SpinAppExecutor
+// controller.
+func (r *ClusterSpinAppExecutorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&spinv1alpha1.ClusterSpinAppExecutor{}).
+		// Keep the app count up to date as apps are created, deleted, or
+		// switch executors, and as namespaced executors start or stop
+		// shadowing the cluster executor.
+		Watches(&spinv1alpha1.SpinApp{},
+			handler.EnqueueRequestsFromMapFunc(r.findExecutorForSpinApp),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(&spinv1alpha1.SpinAppExecutor{},
+			handler.EnqueueRequestsFromMapFunc(r.findExecutorWithSameName),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(&nodev1.RuntimeClass{}, handler.EnqueueRequestsFromMapFunc(r.findExecutorsForRuntimeClass)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.findExecutorsForSecret)).
+		Complete(r)
+}
+
+// findExecutorForSpinApp returns a reconcile request for the
+// ClusterSpinAppExecutor referenced by a SpinApp. Apps whose executor resolves
+// to a SpinAppExecutor in their namespace don't use the cluster executor.
+func (r *ClusterSpinAppExecutorReconciler) findExecutorForSpinApp(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := logging.FromContext(ctx)
+
+	app := obj.(*spinv1alpha1.SpinApp)
+	if app.Spec.Executor == "" {
+		return nil
+	}
+
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: app.Namespace, Name: app.Spec.Executor}, &spinv1alpha1.SpinAppExecutor{})
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		// Recount the cluster executor in case the app does use it
+		log.Error(err, "Unable to fetch SpinAppExecutor")
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: app.Spec.Executor}}}
+}
+
+// findExecutorWithSameName returns a reconcile request for the
+// ClusterSpinAppExecutor that is shadowed by a SpinAppExecutor.
+func (r *ClusterSpinAppExecutorReconciler) findExecutorWithSameName(ctx context.Context, obj client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetName()}}}
+}
+
+// findExecutorsForRuntimeClass returns a reconcile request for every
+// ClusterSpinAppExecutor that uses the given RuntimeClass.
+func (r *ClusterSpinAppExecutorReconciler) findExecutorsForRuntimeClass(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.findExecutorsMatching(ctx, func(config *spinv1alpha1.ExecutorDeploymentConfig) bool {
+		return config.RuntimeClassName != nil && *config.RuntimeClassName == obj.GetName()
+	})
+}
+
+// findExecutorsForSecret returns a reconcile request for every
+// ClusterSpinAppExecutor that uses a secret with the name of the given Secret
+// as its CA certificate secret.
+func (r *ClusterSpinAppExecutorReconciler) findExecutorsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.findExecutorsMatching(ctx, func(config *spinv1alpha1.ExecutorDeploymentConfig) bool {
+		return config.CACertSecret == obj.GetName()
+	})
+}
+
+// findExecutorsMatching returns a reconcile request for every
+// ClusterSpinAppExecutor whose deployment config matches.
+func (r *ClusterSpinAppExecutorReconciler) findExecutorsMatching(ctx context.Context, matches func(*spinv1alpha1.ExecutorDeploymentConfig) bool) []reconcile.Request {
+	log := logging.FromContext(ctx)
+
+	var executors spinv1alpha1.ClusterSpinAppExecutorList
+	if err := r.Client.List(ctx, &executors); err != nil {
+		log.Error(err, "Unable to list ClusterSpinAppExecutors")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, executor := range executors.Items {
+		if executor.Spec.DeploymentConfig != nil && matches(executor.Spec.DeploymentConfig) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: executor.Name}})
+		}
+	}
+	return requests
+}
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *ClusterSpinAppExecutorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logging.FromContext(ctx)
+	log.Debug("Reconciling ClusterSpinAppExecutor")
+
+	var executor spinv1alpha1.ClusterSpinAppExecutor
+	if err := r.Client.Get(ctx, req.NamespacedName, &executor); err != nil {
+		log.Error(err, "Unable to fetch ClusterSpinAppExecutor")
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// ClusterSpinAppExecutor has been requested for deletion
+	if !executor.DeletionTimestamp.IsZero() {
+		err := r.handleDeletion(ctx, &executor)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		err = r.removeFinalizer(ctx, &executor)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Make sure the finalizer is present
+	if err := r.ensureFinalizer(ctx, &executor); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	err := r.updateStatus(ctx, &executor)
+	if err != nil {
+		log.Error(err, "Unable to update status")
+	}
+	return ctrl.Result{}, client.IgnoreNotFound(err)
+}
+
+// updateStatus records the number of SpinApps using the ClusterSpinAppExecutor
+// and the health of the resources it depends on. The CA certificate secret is
+// expected in the namespace of every app using the executor.
+func (r *ClusterSpinAppExecutorReconciler) updateStatus(ctx context.Context, executor *spinv1alpha1.ClusterSpinAppExecutor) error {
+	dependents, err := r.dependentSpinApps(ctx, executor.Name)
+	if err != nil {
+		return err
+	}
+
+	namespaces := map[string]struct{}{}
+	for _, app := range dependents {
+		namespaces[app.Namespace] = struct{}{}
+	}
+
+	status := executor.Status.DeepCopy()
+	if err := observeExecutorStatus(ctx, r.Client, status, &executor.Spec, executor.Generation,
+		len(dependents), slices.Sorted(maps.Keys(namespaces))); err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(status, &executor.Status) {
+		return nil
+	}
+
+	executor.Status = *status
+	return r.Client.Status().Update(ctx, executor)
+}
+
+// handleDeletion makes sure no SpinApps in any namespace are dependent on the
+// ClusterSpinAppExecutor before allowing it to be deleted.
+func (r *ClusterSpinAppExecutorReconciler) handleDeletion(ctx context.Context, executor *spinv1alpha1.ClusterSpinAppExecutor) error {
+	log := logging.FromContext(ctx)
+
+	dependents, err := r.dependentSpinApps(ctx, executor.Name)
+	if err != nil {
+		log.Error(err, "Unable to list SpinApps")
+		return err
+	}
+
+	if len(dependents) > 0 {
+		r.Recorder.Event(executor, "Warning", "DeletionBlocked", "Cannot delete ClusterSpinAppExecutor with dependent SpinApps")
+		return errors.New("cannot delete ClusterSpinAppExecutor with dependent SpinApps")
+	}
+
+	return nil
+}
+
+// dependentSpinApps returns the SpinApps across all namespaces that resolve to
+// the named ClusterSpinAppExecutor. Apps in namespaces with a SpinAppExecutor of
+// the same name use the namespaced executor and are not included.
+func (r *ClusterSpinAppExecutorReconciler) dependentSpinApps(ctx context.Context, name string) ([]spinv1alpha1.SpinApp, error) {
+	var spinApps spinv1alpha1.SpinAppList
+	if err := r.Client.List(ctx, &spinApps, client.MatchingFields{spinAppExecutorKey: name}); err != nil {
+		return nil, err
+	}
+
+	shadowed := map[string]bool{}
+	var dependents []spinv1alpha1.SpinApp
+	for _, app := range spinApps.Items {
+		isShadowed, ok := shadowed[app.Namespace]
+		if !ok {
+			err := r.Client.Get(ctx, types.NamespacedName{Namespace: app.Namespace, Name: name}, &spinv1alpha1.SpinAppExecutor{})
+			if client.IgnoreNotFound(err) != nil {
+				return nil, err
+			}
+			isShadowed = !apierrors.IsNotFound(err)
+			shadowed[app.Namespace] = isShadowed
+		}
+
+		if !isShadowed {
+			dependents = append(dependents, app)
+		}
+	}
+
+	return dependents, nil
+}
+
+// removeFinalizer removes the finalizer from a ClusterSpinAppExecutor.
+func (r *ClusterSpinAppExecutorReconciler) removeFinalizer(ctx context.Context, executor *spinv1alpha1.ClusterSpinAppExecutor) error {
+	if controllerutil.ContainsFinalizer(executor, SpinOperatorFinalizer) {
+		controllerutil.RemoveFinalizer(executor, SpinOperatorFinalizer)
+		if err := r.Client.Update(ctx, executor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureFinalizer ensures the finalizer is present on a ClusterSpinAppExecutor.
+func (r *ClusterSpinAppExecutorReconciler) ensureFinalizer(ctx context.Context, executor *spinv1alpha1.ClusterSpinAppExecutor) error {
+	if !controllerutil.ContainsFinalizer(executor, SpinOperatorFinalizer) {
+		controllerutil.AddFinalizer(executor, SpinOperatorFinalizer)
+		if err := r.Client.Update(ctx, executor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
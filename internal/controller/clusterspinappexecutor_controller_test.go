@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/generics"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	controllerconfig "sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func setupClusterExecutorController(t *testing.T) (*envTestState, ctrl.Manager, *ClusterSpinAppExecutorReconciler) {
+	t.Helper()
+
+	envTest := SetupEnvTest(t)
+
+	opts := zap.Options{
+		Development: true,
+	}
+	logger := zap.New(zap.UseFlagOptions(&opts))
+
+	// B/c of https://github.com/kubernetes-sigs/controller-runtime/issues/2937
+	skipNameValidation := true
+
+	mgr, err := ctrl.NewManager(envTest.cfg, manager.Options{
+		Metrics:    metricsserver.Options{BindAddress: "0"},
+		Scheme:     envTest.scheme,
+		Logger:     logger,
+		Controller: controllerconfig.Controller{SkipNameValidation: &skipNameValidation},
+	})
+
+	require.NoError(t, err)
+
+	ctrlr := &ClusterSpinAppExecutorReconciler{
+		Client:   envTest.k8sClient,
+		Scheme:   envTest.scheme,
+		Recorder: mgr.GetEventRecorderFor("clusterspinappexecutor-reconciler"),
+	}
+
+	require.NoError(t, ctrlr.SetupWithManager(mgr))
+
+	return envTest, mgr, ctrlr
+}
+
+func TestClusterSpinAppExecutorReconcile_StartupShutdown(t *testing.T) {
+	t.Parallel()
+
+	_, mgr, _ := setupClusterExecutorController(t)
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+	require.NoError(t, mgr.Start(ctx))
+}
+
+func TestClusterSpinAppExecutor_DependentSpinApps(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	app := func(name, namespace, executor string) *spinv1alpha1.SpinApp {
+		return &spinv1alpha1.SpinApp{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       spinv1alpha1.SpinAppSpec{Executor: executor},
+		}
+	}
+
+	r := &ClusterSpinAppExecutorReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).
+			WithIndex(&spinv1alpha1.SpinApp{}, spinAppExecutorKey, func(obj client.Object) []string {
+				return []string{obj.(*spinv1alpha1.SpinApp).Spec.Executor}
+			}).
+			WithObjects(
+				testClusterContainerdShimSpinExecutor(),
+				app("app-a", "team-a", "test-executor"),
+				app("app-b", "team-b", "test-executor"),
+				app("app-c", "team-b", "another-executor"),
+				// team-c has its own executor with the same name, so its apps don't
+				// depend on the cluster executor.
+				app("app-d", "team-c", "test-executor"),
+				&spinv1alpha1.SpinAppExecutor{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-executor", Namespace: "team-c"},
+				},
+			).Build(),
+		Scheme: scheme,
+	}
+
+	dependents, err := r.dependentSpinApps(context.Background(), "test-executor")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"app-a", "app-b"},
+		generics.MapList(dependents, func(app spinv1alpha1.SpinApp) string { return app.Name }))
+
+	dependents, err = r.dependentSpinApps(context.Background(), "unused-executor")
+	require.NoError(t, err)
+	require.Empty(t, dependents)
+}
+
+func TestClusterSpinAppExecutor_FindExecutorForSpinApp(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	r := &ClusterSpinAppExecutorReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			testClusterContainerdShimSpinExecutor(),
+			&spinv1alpha1.SpinAppExecutor{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-executor", Namespace: "team-c"},
+			},
+		).Build(),
+		Scheme: scheme,
+	}
+
+	app := &spinv1alpha1.SpinApp{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"},
+		Spec:       spinv1alpha1.SpinAppSpec{Executor: "test-executor"},
+	}
+	require.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "test-executor"}}},
+		r.findExecutorForSpinApp(context.Background(), app))
+
+	// The executor of apps in team-c resolves to the namespaced executor
+	app.Namespace = "team-c"
+	require.Empty(t, r.findExecutorForSpinApp(context.Background(), app))
+}
+
+func testClusterContainerdShimSpinExecutor() *spinv1alpha1.ClusterSpinAppExecutor {
+	return &spinv1alpha1.ClusterSpinAppExecutor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-executor",
+		},
+		Spec: spinv1alpha1.SpinAppExecutorSpec{
+			CreateDeployment: true,
+			DeploymentConfig: &spinv1alpha1.ExecutorDeploymentConfig{
+				RuntimeClassName: generics.Ptr("test-runtime"),
+			},
+		},
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/pkg/spinapp"
+)
+
+// spinAppConditionDegraded reports whether pods of a SpinApp are failing to be
+// created, scheduled or started.
+const spinAppConditionDegraded = "Degraded"
+
+// degradedWaitingReasons are the reasons of waiting containers that won't
+// recover without intervention.
+var degradedWaitingReasons = []string{
+	"CrashLoopBackOff",
+	"CreateContainerConfigError",
+	"CreateContainerError",
+	"ErrImagePull",
+	"ImagePullBackOff",
+	"InvalidImageName",
+	"RunContainerError",
+}
+
+// podFailure is a failure shared by one or more pods of a SpinApp. Only one
+// of the pods is named, so that the condition message stays short for apps
+// with many replicas.
+type podFailure struct {
+	reason  string
+	message string
+	pods    int
+	example string
+}
+
+// SpinAppPodSelector selects the pods created for SpinApps. The manager only
+// caches these pods.
+func SpinAppPodSelector() labels.Selector {
+	requirement, err := labels.NewRequirement(spinapp.NameLabelKey, selection.Exists, nil)
+	if err != nil {
+		panic(err)
+	}
+	return labels.NewSelector().Add(*requirement)
+}
+
+// findSpinAppForPod returns a reconcile request for the SpinApp a pod was
+// created for.
+func (r *SpinAppReconciler) findSpinAppForPod(_ context.Context, obj client.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()[spinapp.NameLabelKey]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetNamespace()}}}
+}
+
+// listPodsForApp lists the pods of a SpinApp, including those of its canary.
+func (r *SpinAppReconciler) listPodsForApp(ctx context.Context, app *spinv1alpha1.SpinApp) ([]corev1.Pod, error) {
+	var pods corev1.PodList
+	if err := r.Client.List(ctx, &pods,
+		client.InNamespace(app.Namespace),
+		client.MatchingLabels(constructAppLabels(app))); err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return pods.Items, nil
+}
+
+// degradedCondition aggregates the failures of the pods of a SpinApp and pods
+// its Deployment failed to create into the Degraded condition of the app.
+func degradedCondition(deployment *appsv1.Deployment, pods []corev1.Pod) metav1.Condition {
+	failures := map[string]*podFailure{}
+	observe := func(reason, message, pod string) {
+		failure, ok := failures[reason]
+		if !ok {
+			failure = &podFailure{reason: reason, message: message}
+			failures[reason] = failure
+		}
+		if pod != "" {
+			failure.pods++
+			if failure.example == "" || pod < failure.example {
+				failure.example = pod
+			}
+		}
+	}
+
+	// Pods rejected at creation, e.g because their RuntimeClass doesn't exist,
+	// are only reported on the Deployment.
+	for _, dc := range deployment.Status.Conditions {
+		if dc.Type == appsv1.DeploymentReplicaFailure && dc.Status == corev1.ConditionTrue {
+			observe(dc.Reason, dc.Message, "")
+		}
+	}
+
+	for _, pod := range pods {
+		if !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		for _, pc := range pod.Status.Conditions {
+			if pc.Type == corev1.PodScheduled && pc.Status == corev1.ConditionFalse &&
+				pc.Reason == corev1.PodReasonUnschedulable {
+				observe(pc.Reason, pc.Message, pod.Name)
+			}
+		}
+
+		statuses := slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses)
+		for _, status := range statuses {
+			waiting := status.State.Waiting
+			if waiting != nil && slices.Contains(degradedWaitingReasons, waiting.Reason) {
+				observe(waiting.Reason, waiting.Message, pod.Name)
+				break
+			}
+		}
+	}
+
+	if len(failures) == 0 {
+		return metav1.Condition{
+			Type:    spinAppConditionDegraded,
+			Status:  metav1.ConditionFalse,
+			Reason:  "PodsHealthy",
+			Message: "No pod failures observed",
+		}
+	}
+
+	sorted := make([]*podFailure, 0, len(failures))
+	for _, failure := range failures {
+		sorted = append(sorted, failure)
+	}
+	slices.SortFunc(sorted, func(a, b *podFailure) int {
+		return cmp.Compare(a.reason, b.reason)
+	})
+
+	messages := make([]string, len(sorted))
+	for idx, failure := range sorted {
+		message := failure.reason
+		if failure.message != "" {
+			message += ": " + failure.message
+		}
+		switch {
+		case failure.pods == 1:
+			message += fmt.Sprintf(" (pod %s)", failure.example)
+		case failure.pods > 1:
+			message += fmt.Sprintf(" (%d pods, e.g. %s)", failure.pods, failure.example)
+		}
+		messages[idx] = message
+	}
+
+	reason := sorted[0].reason
+	if len(sorted) > 1 {
+		reason = "MultiplePodFailures"
+	}
+
+	return metav1.Condition{
+		Type:    spinAppConditionDegraded,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: strings.Join(messages, "; "),
+	}
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/spinkube/spin-operator/internal/generics"
+	"github.com/spinkube/spin-operator/pkg/spinapp"
+)
+
+func waitingPod(name, reason, message string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "my-app",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: message}},
+			}},
+		},
+	}
+}
+
+func TestDegradedCondition(t *testing.T) {
+	t.Parallel()
+
+	deployment := &appsv1.Deployment{}
+
+	// Pods that are starting up aren't degraded
+	condition := degradedCondition(deployment, []corev1.Pod{
+		waitingPod("my-app-1", "ContainerCreating", ""),
+		{ObjectMeta: metav1.ObjectMeta{Name: "my-app-2"}},
+	})
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, "PodsHealthy", condition.Reason)
+
+	// Failures are aggregated by reason, naming a single pod however many
+	// are failing
+	var failing []corev1.Pod
+	for idx := 50; idx > 0; idx-- {
+		failing = append(failing, waitingPod(fmt.Sprintf("my-app-%02d", idx),
+			"ImagePullBackOff", `Back-off pulling image "ghcr.io/missing"`))
+	}
+	condition = degradedCondition(deployment, failing)
+	require.Equal(t, metav1.ConditionTrue, condition.Status)
+	require.Equal(t, "ImagePullBackOff", condition.Reason)
+	require.Equal(t, `ImagePullBackOff: Back-off pulling image "ghcr.io/missing" (50 pods, e.g. my-app-01)`, condition.Message)
+
+	// Scheduling failures, crash loops and pods the Deployment failed to
+	// create are all reported
+	unschedulable := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-app-3"},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Reason:  corev1.PodReasonUnschedulable,
+				Message: "0/3 nodes are available",
+			}},
+		},
+	}
+	terminating := waitingPod("my-app-4", "CrashLoopBackOff", "")
+	terminating.DeletionTimestamp = generics.Ptr(metav1.Now())
+	deployment.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:    appsv1.DeploymentReplicaFailure,
+		Status:  corev1.ConditionTrue,
+		Reason:  "FailedCreate",
+		Message: `pods "my-app-5" is forbidden: RuntimeClass "wasmtime-spin-v2" not found`,
+	}}
+
+	condition = degradedCondition(deployment, []corev1.Pod{
+		waitingPod("my-app-1", "CrashLoopBackOff", "back-off 10s restarting failed container"),
+		unschedulable,
+		terminating,
+	})
+	require.Equal(t, metav1.ConditionTrue, condition.Status)
+	require.Equal(t, "MultiplePodFailures", condition.Reason)
+	require.Equal(t, "CrashLoopBackOff: back-off 10s restarting failed container (pod my-app-1); "+
+		`FailedCreate: pods "my-app-5" is forbidden: RuntimeClass "wasmtime-spin-v2" not found; `+
+		"Unschedulable: 0/3 nodes are available (pod my-app-3)", condition.Message)
+}
+
+func TestFindSpinAppForPod(t *testing.T) {
+	t.Parallel()
+
+	r := &SpinAppReconciler{}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "my-app-abc",
+		Namespace: "default",
+		Labels:    map[string]string{spinapp.NameLabelKey: "my-app"},
+	}}
+
+	requests := r.findSpinAppForPod(context.Background(), pod)
+	require.Len(t, requests, 1)
+	require.Equal(t, types.NamespacedName{Name: "my-app", Namespace: "default"}, requests[0].NamespacedName)
+	require.True(t, SpinAppPodSelector().Matches(labels.Set(pod.Labels)))
+
+	pod.Labels = nil
+	require.Empty(t, r.findSpinAppForPod(context.Background(), pod))
+	require.False(t, SpinAppPodSelector().Matches(labels.Set(pod.Labels)))
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/logging"
+)
+
+var (
+	// spinAppSecretRefsKey indexes SpinApps by the names of the Secrets they
+	// read configuration from.
+	spinAppSecretRefsKey = "spec.secretRefs"
+
+	// spinAppConfigMapRefsKey indexes SpinApps by the names of the ConfigMaps
+	// they read configuration from.
+	spinAppConfigMapRefsKey = "spec.configMapRefs"
+)
+
+// appDependencies are the Secrets and ConfigMaps, in the namespace of a
+// SpinApp, that the app reads configuration from.
+type appDependencies struct {
+	Secrets    []string
+	ConfigMaps []string
+
+	// SecretKeys and ConfigMapKeys hold the sorted keys that the app reads from
+	// each dependency. A nil list means that the app reads every key, as it
+	// does for the LoadFromSecret Secret.
+	SecretKeys    map[string][]string
+	ConfigMapKeys map[string][]string
+}
+
+// dependenciesForApp returns the sorted and de-duplicated names of all Secrets
+// and ConfigMaps that are referenced by the runtime config (including
+// LoadFromSecret) and the variables of a SpinApp, along with the keys read
+// from them.
+func dependenciesForApp(app *spinv1alpha1.SpinApp) appDependencies {
+	secrets := map[string]map[string]struct{}{}
+	configMaps := map[string]map[string]struct{}{}
+
+	addKey := func(deps map[string]map[string]struct{}, name, key string) {
+		keys, ok := deps[name]
+		if ok && keys == nil {
+			return
+		}
+		if !ok {
+			keys = map[string]struct{}{}
+			deps[name] = keys
+		}
+		keys[key] = struct{}{}
+	}
+
+	runtimeConfig := app.Spec.RuntimeConfig
+	if runtimeConfig.LoadFromSecret != "" {
+		secrets[runtimeConfig.LoadFromSecret] = nil
+	}
+
+	var configOptions []spinv1alpha1.RuntimeConfigOption
+	if runtimeConfig.LLMCompute != nil {
+		configOptions = append(configOptions, runtimeConfig.LLMCompute.Options...)
+	}
+	for _, kvStore := range runtimeConfig.KeyValueStores {
+		configOptions = append(configOptions, kvStore.Options...)
+	}
+	for _, sqlDB := range runtimeConfig.SqliteDatabases {
+		configOptions = append(configOptions, sqlDB.Options...)
+	}
+	for _, provider := range runtimeConfig.VariablesProviders {
+		configOptions = append(configOptions, provider.Options...)
+	}
+
+	for _, opt := range configOptions {
+		if opt.ValueFrom == nil {
+			continue
+		}
+		if ref := opt.ValueFrom.SecretKeyRef; ref != nil {
+			addKey(secrets, ref.Name, ref.Key)
+		}
+		if ref := opt.ValueFrom.ConfigMapKeyRef; ref != nil {
+			addKey(configMaps, ref.Name, ref.Key)
+		}
+	}
+
+	for _, variable := range app.Spec.Variables {
+		if variable.ValueFrom == nil {
+			continue
+		}
+		if ref := variable.ValueFrom.SecretKeyRef; ref != nil {
+			addKey(secrets, ref.Name, ref.Key)
+		}
+		if ref := variable.ValueFrom.ConfigMapKeyRef; ref != nil {
+			addKey(configMaps, ref.Name, ref.Key)
+		}
+	}
+
+	sortedKeys := func(deps map[string]map[string]struct{}) map[string][]string {
+		result := make(map[string][]string, len(deps))
+		for name, keys := range deps {
+			if keys != nil {
+				result[name] = slices.Sorted(maps.Keys(keys))
+			} else {
+				result[name] = nil
+			}
+		}
+		return result
+	}
+
+	return appDependencies{
+		Secrets:       slices.Sorted(maps.Keys(secrets)),
+		ConfigMaps:    slices.Sorted(maps.Keys(configMaps)),
+		SecretKeys:    sortedKeys(secrets),
+		ConfigMapKeys: sortedKeys(configMaps),
+	}
+}
+
+// dependencyChecksum computes a checksum over the keys that a SpinApp reads
+// from the Secrets and ConfigMaps it depends on, so that changes to other keys
+// don't roll its pods. It returns an empty string when the app has no
+// dependencies. Missing dependencies and keys are included in the checksum so
+// pods are rolled once they're created.
+func dependencyChecksum(ctx context.Context, c client.Client, app *spinv1alpha1.SpinApp) (string, error) {
+	deps := dependenciesForApp(app)
+	if len(deps.Secrets) == 0 && len(deps.ConfigMaps) == 0 {
+		return "", nil
+	}
+
+	hash := sha256.New()
+
+	for _, name := range deps.Secrets {
+		var secret corev1.Secret
+		err := c.Get(ctx, types.NamespacedName{Namespace: app.Namespace, Name: name}, &secret)
+		if client.IgnoreNotFound(err) != nil {
+			return "", fmt.Errorf("failed to fetch secret %s/%s: %w", app.Namespace, name, err)
+		}
+
+		fmt.Fprintf(hash, "secret/%s\n", name)
+		if apierrors.IsNotFound(err) {
+			fmt.Fprint(hash, "missing\n")
+			continue
+		}
+		hashData(hash, secret.Data, deps.SecretKeys[name])
+	}
+
+	for _, name := range deps.ConfigMaps {
+		var cm corev1.ConfigMap
+		err := c.Get(ctx, types.NamespacedName{Namespace: app.Namespace, Name: name}, &cm)
+		if client.IgnoreNotFound(err) != nil {
+			return "", fmt.Errorf("failed to fetch configmap %s/%s: %w", app.Namespace, name, err)
+		}
+
+		fmt.Fprintf(hash, "configmap/%s\n", name)
+		if apierrors.IsNotFound(err) {
+			fmt.Fprint(hash, "missing\n")
+			continue
+		}
+		data := maps.Clone(cm.BinaryData)
+		if data == nil {
+			data = map[string][]byte{}
+		}
+		for key, value := range cm.Data {
+			data[key] = []byte(value)
+		}
+		hashData(hash, data, deps.ConfigMapKeys[name])
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashData writes the given keys of data to hash, or every key when keys is
+// nil.
+func hashData(hash io.Writer, data map[string][]byte, keys []string) {
+	if keys == nil {
+		keys = slices.Sorted(maps.Keys(data))
+	}
+	for _, key := range keys {
+		value, ok := data[key]
+		if !ok {
+			fmt.Fprintf(hash, "%s missing\n", key)
+			continue
+		}
+		fmt.Fprintf(hash, "%s=%x\n", key, value)
+	}
+}
+
+// findSpinAppsForSecret returns a reconcile request for every SpinApp that
+// depends on the given Secret.
+func (r *SpinAppReconciler) findSpinAppsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.findSpinAppsMatchingField(ctx, obj, spinAppSecretRefsKey)
+}
+
+// findSpinAppsForConfigMap returns a reconcile request for every SpinApp that
+// depends on the given ConfigMap.
+func (r *SpinAppReconciler) findSpinAppsForConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.findSpinAppsMatchingField(ctx, obj, spinAppConfigMapRefsKey)
+}
+
+// findSpinAppsMatchingField returns a reconcile request for every SpinApp in
+// the namespace of obj whose indexed field matches the name of obj.
+func (r *SpinAppReconciler) findSpinAppsMatchingField(ctx context.Context, obj client.Object, field string) []reconcile.Request {
+	log := logging.FromContext(ctx)
+
+	var spinApps spinv1alpha1.SpinAppList
+	if err := r.Client.List(ctx, &spinApps,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{field: obj.GetName()}); err != nil {
+		log.Error(err, "Unable to list SpinApps", "field", field, "name", obj.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, len(spinApps.Items))
+	for idx, app := range spinApps.Items {
+		requests[idx] = reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: app.Namespace,
+			Name:      app.Name,
+		}}
+	}
+	return requests
+}
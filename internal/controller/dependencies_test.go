@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+)
+
+func spinAppWithDependencies() *spinv1alpha1.SpinApp {
+	app := minimalSpinApp()
+	app.Spec.RuntimeConfig.KeyValueStores = []spinv1alpha1.KeyValueStoreConfig{{
+		Name: "default",
+		Type: "redis",
+		Options: []spinv1alpha1.RuntimeConfigOption{{
+			Name: "url",
+			ValueFrom: &spinv1alpha1.RuntimeConfigVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "redis"},
+					Key:                  "url",
+				},
+			},
+		}},
+	}}
+	app.Spec.Variables = []spinv1alpha1.SpinVar{
+		{
+			Name: "password",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "redis"},
+					Key:                  "password",
+				},
+			},
+		},
+		{
+			Name: "greeting",
+			ValueFrom: &corev1.EnvVarSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "greetings"},
+					Key:                  "greeting",
+				},
+			},
+		},
+		{
+			Name:  "static",
+			Value: "value",
+		},
+	}
+	return app
+}
+
+func TestDependenciesForApp(t *testing.T) {
+	t.Parallel()
+
+	deps := dependenciesForApp(minimalSpinApp())
+	require.Empty(t, deps.Secrets)
+	require.Empty(t, deps.ConfigMaps)
+
+	deps = dependenciesForApp(spinAppWithDependencies())
+	require.Equal(t, []string{"redis"}, deps.Secrets)
+	require.Equal(t, []string{"greetings"}, deps.ConfigMaps)
+	require.Equal(t, map[string][]string{"redis": {"password", "url"}}, deps.SecretKeys)
+	require.Equal(t, map[string][]string{"greetings": {"greeting"}}, deps.ConfigMapKeys)
+
+	// Secrets of variables providers are dependencies too
+	app := spinAppWithDependencies()
+	app.Spec.RuntimeConfig.VariablesProviders = []spinv1alpha1.VariablesProviderConfig{{
+		Type: "vault",
+		Options: []spinv1alpha1.RuntimeConfigOption{{
+			Name: "token",
+			ValueFrom: &spinv1alpha1.RuntimeConfigVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "vault"},
+					Key:                  "token",
+				},
+			},
+		}},
+	}}
+	require.Equal(t, []string{"redis", "vault"}, dependenciesForApp(app).Secrets)
+
+	app = minimalSpinApp()
+	app.Spec.RuntimeConfig.LoadFromSecret = "my-runtime-config"
+	deps = dependenciesForApp(app)
+	require.Equal(t, []string{"my-runtime-config"}, deps.Secrets)
+	require.Empty(t, deps.ConfigMaps)
+	// Every key of the LoadFromSecret Secret is read
+	require.Equal(t, map[string][]string{"my-runtime-config": nil}, deps.SecretKeys)
+}
+
+func TestDependencyChecksum(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: "default"},
+		Data: map[string][]byte{
+			"url":      []byte("redis://localhost:6379"),
+			"password": []byte("hunter2"),
+		},
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "greetings", Namespace: "default"},
+		Data:       map[string]string{"greeting": "hello"},
+	}
+
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, cm).Build()
+
+	// Apps without dependencies don't get a checksum
+	checksum, err := dependencyChecksum(ctx, c, minimalSpinApp())
+	require.NoError(t, err)
+	require.Empty(t, checksum)
+
+	app := spinAppWithDependencies()
+	initial, err := dependencyChecksum(ctx, c, app)
+	require.NoError(t, err)
+	require.NotEmpty(t, initial)
+
+	// The checksum is stable when nothing changes
+	again, err := dependencyChecksum(ctx, c, app)
+	require.NoError(t, err)
+	require.Equal(t, initial, again)
+
+	// Rotating a secret changes the checksum
+	secret.Data["password"] = []byte("correct-horse-battery-staple")
+	require.NoError(t, c.Update(ctx, secret))
+	rotated, err := dependencyChecksum(ctx, c, app)
+	require.NoError(t, err)
+	require.NotEqual(t, initial, rotated)
+
+	// Changing keys the app doesn't read leaves the checksum alone
+	secret.Data["unused"] = []byte("value")
+	require.NoError(t, c.Update(ctx, secret))
+	unrelated, err := dependencyChecksum(ctx, c, app)
+	require.NoError(t, err)
+	require.Equal(t, rotated, unrelated)
+
+	// Removing a dependency changes the checksum rather than failing
+	require.NoError(t, c.Delete(ctx, cm))
+	missing, err := dependencyChecksum(ctx, c, app)
+	require.NoError(t, err)
+	require.NotEqual(t, rotated, missing)
+}
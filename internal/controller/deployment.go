@@ -14,9 +14,13 @@ import (
 	"github.com/spinkube/spin-operator/pkg/spinapp"
 )
 
+// runtimeConfigVolumeName is the name of the volume used to mount the runtime
+// config secret into app pods.
+const runtimeConfigVolumeName = "spin-runtime-config"
+
 func constructRuntimeConfigSecretMount(_ctx context.Context, secretName string) (corev1.Volume, corev1.VolumeMount) {
 	volume := corev1.Volume{
-		Name: "spin-runtime-config",
+		Name: runtimeConfigVolumeName,
 		VolumeSource: corev1.VolumeSource{
 			Secret: &corev1.SecretVolumeSource{
 				SecretName: secretName,
@@ -31,7 +35,7 @@ func constructRuntimeConfigSecretMount(_ctx context.Context, secretName string)
 		},
 	}
 	volumeMount := corev1.VolumeMount{
-		Name:      "spin-runtime-config",
+		Name:      runtimeConfigVolumeName,
 		ReadOnly:  true,
 		MountPath: "/runtime-config.toml",
 		SubPath:   "runtime-config.toml",
@@ -144,7 +148,7 @@ func ConstructEnvForApp(ctx context.Context, app *spinv1alpha1.SpinApp, listenPo
 	return envs
 }
 
-func SpinHealthCheckToCoreProbe(probe *spinv1alpha1.HealthProbe) (*corev1.Probe, error) {
+func SpinHealthCheckToCoreProbe(probe *spinv1alpha1.HealthProbe, listenPort int32) (*corev1.Probe, error) {
 	if probe == nil {
 		return nil, nil
 	}
@@ -160,7 +164,7 @@ func SpinHealthCheckToCoreProbe(probe *spinv1alpha1.HealthProbe) (*corev1.Probe,
 		ProbeHandler: corev1.ProbeHandler{
 			HTTPGet: &corev1.HTTPGetAction{
 				Path: probe.HTTPGet.Path,
-				Port: intstr.FromInt(spinapp.DefaultHTTPPort),
+				Port: intstr.FromInt32(listenPort),
 				HTTPHeaders: generics.MapList(probe.HTTPGet.HTTPHeaders, func(h spinv1alpha1.HTTPHealthProbeHeader) corev1.HTTPHeader {
 					return corev1.HTTPHeader{
 						Name:  h.Name,
@@ -177,20 +181,43 @@ func SpinHealthCheckToCoreProbe(probe *spinv1alpha1.HealthProbe) (*corev1.Probe,
 	}, nil
 }
 
-func ConstructPodHealthChecks(app *spinv1alpha1.SpinApp) (readiness *corev1.Probe, liveness *corev1.Probe, err error) {
+func ConstructPodHealthChecks(app *spinv1alpha1.SpinApp, listenPort int32) (readiness *corev1.Probe, liveness *corev1.Probe, err error) {
 	if app.Spec.Checks.Readiness == nil && app.Spec.Checks.Liveness == nil {
 		return nil, nil, nil
 	}
 
-	readiness, err = SpinHealthCheckToCoreProbe(app.Spec.Checks.Readiness)
+	readiness, err = SpinHealthCheckToCoreProbe(app.Spec.Checks.Readiness, listenPort)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to construct readiness probe: %w", err)
 	}
 
-	liveness, err = SpinHealthCheckToCoreProbe(app.Spec.Checks.Liveness)
+	liveness, err = SpinHealthCheckToCoreProbe(app.Spec.Checks.Liveness, listenPort)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to construct liveness probe: %w", err)
 	}
 
 	return readiness, liveness, nil
 }
+
+// resolveListenPort returns the port a SpinApp listens on inside its pods. The
+// webhook defaults the port of the app, but apps created before it did fall
+// back to the port of the executor and then spinapp.DefaultHTTPPort.
+func resolveListenPort(app *spinv1alpha1.SpinApp, config *spinv1alpha1.ExecutorDeploymentConfig) int32 {
+	if app.Spec.ListenPort != 0 {
+		return app.Spec.ListenPort
+	}
+	if config != nil && config.ListenPort != nil {
+		return *config.ListenPort
+	}
+	return spinapp.DefaultHTTPPort
+}
+
+// constructPodSelectorLabels returns the labels that select the pods of a
+// SpinApp.
+func constructPodSelectorLabels(app *spinv1alpha1.SpinApp) map[string]string {
+	statusKey, statusValue := spinapp.ConstructStatusReadyLabel(app.Name)
+	return map[string]string{
+		spinapp.NameLabelKey: app.Name,
+		statusKey:            statusValue,
+	}
+}
@@ -266,7 +266,7 @@ func TestSpinHealthCheckToCoreProbe(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			result, err := SpinHealthCheckToCoreProbe(test.probe)
+			result, err := SpinHealthCheckToCoreProbe(test.probe, 80)
 			if test.expectedErr != "" {
 				require.Error(t, err)
 				require.ErrorContains(t, err, test.expectedErr)
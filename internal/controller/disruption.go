@@ -0,0 +1,58 @@
+package controller
+
+import (
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+)
+
+// resolveDisruptionBudget returns the disruption budget of a SpinApp, falling
+// back to defaultBudget for apps that run more than one replica. nil is
+// returned when the app shouldn't have a PodDisruptionBudget.
+func resolveDisruptionBudget(app *spinv1alpha1.SpinApp, defaultBudget *spinv1alpha1.DisruptionBudget) *spinv1alpha1.DisruptionBudget {
+	if app.Spec.DisruptionBudget != nil {
+		return app.Spec.DisruptionBudget
+	}
+	if defaultBudget == nil {
+		return nil
+	}
+
+	// A single replica can't be protected, and the replica count of apps
+	// scaled by an autoscaler that isn't managed by the operator is unknown.
+	replicas := app.Spec.Replicas
+	if app.Spec.EnableAutoscaling {
+		replicas = 0
+		if app.Spec.Autoscaling != nil && app.Spec.Autoscaling.MinReplicas != nil {
+			replicas = *app.Spec.Autoscaling.MinReplicas
+		}
+	}
+	if replicas <= 1 {
+		return nil
+	}
+
+	return defaultBudget
+}
+
+// constructPodDisruptionBudget builds a policyv1.PodDisruptionBudget that
+// selects the ready pods of a SpinApp.
+func constructPodDisruptionBudget(app *spinv1alpha1.SpinApp, budget *spinv1alpha1.DisruptionBudget) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PodDisruptionBudget",
+			APIVersion: "policy/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      app.Name,
+			Namespace: app.Namespace,
+			Labels:    constructAppLabels(app),
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable:   budget.MinAvailable,
+			MaxUnavailable: budget.MaxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: constructPodSelectorLabels(app),
+			},
+		},
+	}
+}
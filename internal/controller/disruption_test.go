@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/generics"
+)
+
+func TestResolveDisruptionBudget(t *testing.T) {
+	t.Parallel()
+
+	defaultBudget := &spinv1alpha1.DisruptionBudget{MaxUnavailable: generics.Ptr(intstr.FromInt32(1))}
+	appBudget := &spinv1alpha1.DisruptionBudget{MinAvailable: generics.Ptr(intstr.FromString("50%"))}
+
+	app := minimalSpinApp()
+	app.Spec.Replicas = 1
+	require.Nil(t, resolveDisruptionBudget(app, nil))
+	// Single replica apps can't be protected by the default
+	require.Nil(t, resolveDisruptionBudget(app, defaultBudget))
+
+	app.Spec.Replicas = 3
+	require.Nil(t, resolveDisruptionBudget(app, nil))
+	require.Equal(t, defaultBudget, resolveDisruptionBudget(app, defaultBudget))
+
+	app.Spec.DisruptionBudget = appBudget
+	require.Equal(t, appBudget, resolveDisruptionBudget(app, nil))
+	require.Equal(t, appBudget, resolveDisruptionBudget(app, defaultBudget))
+
+	// Autoscaled apps use their minimum replicas
+	app.Spec.DisruptionBudget = nil
+	app.Spec.Replicas = 0
+	app.Spec.EnableAutoscaling = true
+	require.Nil(t, resolveDisruptionBudget(app, defaultBudget))
+	app.Spec.Autoscaling = &spinv1alpha1.Autoscaling{MaxReplicas: 5}
+	require.Nil(t, resolveDisruptionBudget(app, defaultBudget))
+	app.Spec.Autoscaling.MinReplicas = generics.Ptr(int32(2))
+	require.Equal(t, defaultBudget, resolveDisruptionBudget(app, defaultBudget))
+}
+
+func TestConstructPodDisruptionBudget(t *testing.T) {
+	t.Parallel()
+
+	app := minimalSpinApp()
+	budget := &spinv1alpha1.DisruptionBudget{MaxUnavailable: generics.Ptr(intstr.FromString("25%"))}
+
+	pdb := constructPodDisruptionBudget(app, budget)
+	require.Equal(t, app.Name, pdb.Name)
+	require.Equal(t, app.Namespace, pdb.Namespace)
+	require.Equal(t, constructAppLabels(app), pdb.Labels)
+	require.Nil(t, pdb.Spec.MinAvailable)
+	require.Equal(t, generics.Ptr(intstr.FromString("25%")), pdb.Spec.MaxUnavailable)
+	// The budget covers the pods the Service routes to
+	require.Equal(t, constructPodSelectorLabels(app), pdb.Spec.Selector.MatchLabels)
+}
+
+func TestDeletePodDisruptionBudget(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	app := minimalSpinApp()
+	app.UID = "my-app-uid"
+
+	owned := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: app.Name, Namespace: app.Namespace},
+	}
+	require.NoError(t, controllerutil.SetControllerReference(app, owned, scheme))
+
+	// A user managed budget that happens to have the same name as another app
+	other := minimalSpinApp()
+	other.Name = "other-app"
+	unowned := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: other.Name, Namespace: other.Namespace},
+	}
+
+	r := &SpinAppReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(owned, unowned).Build(),
+		Scheme: scheme,
+	}
+
+	ctx := context.Background()
+	executor := &spinv1alpha1.SpinAppExecutor{Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true}}
+
+	// Apps without a budget have their PodDisruptionBudget removed
+	require.NoError(t, r.reconcilePodDisruptionBudget(ctx, app, executor))
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(owned), &policyv1.PodDisruptionBudget{})
+	require.True(t, apierrors.IsNotFound(err))
+
+	require.NoError(t, r.deletePodDisruptionBudget(ctx, other))
+	require.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(unowned), &policyv1.PodDisruptionBudget{}))
+}
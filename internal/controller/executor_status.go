@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+)
+
+const (
+	executorConditionReady             = "Ready"
+	executorConditionRuntimeClassFound = "RuntimeClassFound"
+	executorConditionCACertSecretFound = "CACertSecretFound"
+)
+
+// observeExecutorStatus updates the status of an executor with the number of
+// apps using it and the health of the resources its deployment config refers
+// to. The CA certificate secret is looked up in every namespace in
+// caSecretNamespaces.
+func observeExecutorStatus(ctx context.Context, c client.Client, status *spinv1alpha1.SpinAppExecutorStatus,
+	spec *spinv1alpha1.SpinAppExecutorSpec, generation int64, appCount int, caSecretNamespaces []string) error {
+	status.AppCount = int32(appCount)
+
+	ready := metav1.Condition{
+		Type:               executorConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "ExecutorReady",
+		Message:            "Executor is ready to run apps",
+		ObservedGeneration: generation,
+	}
+	notReady := func(reason, message string) {
+		// Report the first problem that was found
+		if ready.Status == metav1.ConditionTrue {
+			ready.Status = metav1.ConditionFalse
+			ready.Reason = reason
+			ready.Message = message
+		}
+	}
+
+	config := spec.DeploymentConfig
+	if !spec.CreateDeployment || config == nil {
+		config = &spinv1alpha1.ExecutorDeploymentConfig{}
+	}
+
+	if config.RuntimeClassName == nil {
+		meta.RemoveStatusCondition(&status.Conditions, executorConditionRuntimeClassFound)
+	} else {
+		err := c.Get(ctx, types.NamespacedName{Name: *config.RuntimeClassName}, &nodev1.RuntimeClass{})
+		if client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to fetch runtime class %s: %w", *config.RuntimeClassName, err)
+		}
+
+		condition := metav1.Condition{
+			Type:               executorConditionRuntimeClassFound,
+			Status:             metav1.ConditionTrue,
+			Reason:             "RuntimeClassFound",
+			Message:            fmt.Sprintf("RuntimeClass %q exists", *config.RuntimeClassName),
+			ObservedGeneration: generation,
+		}
+		if apierrors.IsNotFound(err) {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "RuntimeClassNotFound"
+			condition.Message = fmt.Sprintf("RuntimeClass %q not found", *config.RuntimeClassName)
+			notReady(condition.Reason, condition.Message)
+		}
+		meta.SetStatusCondition(&status.Conditions, condition)
+	}
+
+	// The operator creates the CA certificate secret when InstallDefaultCACerts
+	// is set, so it's only expected to exist up front otherwise.
+	if config.CACertSecret == "" || config.InstallDefaultCACerts {
+		meta.RemoveStatusCondition(&status.Conditions, executorConditionCACertSecretFound)
+	} else {
+		var missing []string
+		for _, namespace := range caSecretNamespaces {
+			err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: config.CACertSecret}, &corev1.Secret{})
+			if client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to fetch secret %s/%s: %w", namespace, config.CACertSecret, err)
+			}
+			if apierrors.IsNotFound(err) {
+				missing = append(missing, namespace)
+			}
+		}
+
+		condition := metav1.Condition{
+			Type:               executorConditionCACertSecretFound,
+			Status:             metav1.ConditionTrue,
+			Reason:             "SecretFound",
+			Message:            fmt.Sprintf("Secret %q exists", config.CACertSecret),
+			ObservedGeneration: generation,
+		}
+		if len(missing) > 0 {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "SecretNotFound"
+			condition.Message = fmt.Sprintf("Secret %q not found in namespace(s) %s", config.CACertSecret, strings.Join(missing, ", "))
+			notReady(condition.Reason, condition.Message)
+		}
+		meta.SetStatusCondition(&status.Conditions, condition)
+	}
+
+	meta.SetStatusCondition(&status.Conditions, ready)
+	return nil
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/generics"
+)
+
+func TestObserveExecutorStatus(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&nodev1.RuntimeClass{ObjectMeta: metav1.ObjectMeta{Name: "wasmtime-spin-v2"}, Handler: "spin"},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "my-ca", Namespace: "team-a"}},
+	).Build()
+
+	spec := &spinv1alpha1.SpinAppExecutorSpec{
+		CreateDeployment: true,
+		DeploymentConfig: &spinv1alpha1.ExecutorDeploymentConfig{
+			RuntimeClassName: generics.Ptr("wasmtime-spin-v2"),
+			CACertSecret:     "my-ca",
+		},
+	}
+
+	// Everything exists
+	status := &spinv1alpha1.SpinAppExecutorStatus{}
+	require.NoError(t, observeExecutorStatus(ctx, c, status, spec, 1, 3, []string{"team-a"}))
+	require.Equal(t, int32(3), status.AppCount)
+	require.True(t, meta.IsStatusConditionTrue(status.Conditions, executorConditionRuntimeClassFound))
+	require.True(t, meta.IsStatusConditionTrue(status.Conditions, executorConditionCACertSecretFound))
+	require.True(t, meta.IsStatusConditionTrue(status.Conditions, executorConditionReady))
+
+	// A missing RuntimeClass makes the executor not ready
+	spec.DeploymentConfig.RuntimeClassName = generics.Ptr("missing")
+	require.NoError(t, observeExecutorStatus(ctx, c, status, spec, 2, 3, []string{"team-a"}))
+	require.True(t, meta.IsStatusConditionFalse(status.Conditions, executorConditionRuntimeClassFound))
+	ready := meta.FindStatusCondition(status.Conditions, executorConditionReady)
+	require.Equal(t, metav1.ConditionFalse, ready.Status)
+	require.Equal(t, "RuntimeClassNotFound", ready.Reason)
+	require.Equal(t, int64(2), ready.ObservedGeneration)
+
+	// A missing CA certificate secret is reported per namespace
+	spec.DeploymentConfig.RuntimeClassName = nil
+	require.NoError(t, observeExecutorStatus(ctx, c, status, spec, 3, 0, []string{"team-a", "team-b"}))
+	require.Equal(t, int32(0), status.AppCount)
+	require.Nil(t, meta.FindStatusCondition(status.Conditions, executorConditionRuntimeClassFound))
+	caCondition := meta.FindStatusCondition(status.Conditions, executorConditionCACertSecretFound)
+	require.Equal(t, metav1.ConditionFalse, caCondition.Status)
+	require.Equal(t, `Secret "my-ca" not found in namespace(s) team-b`, caCondition.Message)
+	require.True(t, meta.IsStatusConditionFalse(status.Conditions, executorConditionReady))
+
+	// The operator creates default CA certificates, so they aren't checked
+	spec.DeploymentConfig.InstallDefaultCACerts = true
+	require.NoError(t, observeExecutorStatus(ctx, c, status, spec, 4, 0, []string{"team-b"}))
+	require.Nil(t, meta.FindStatusCondition(status.Conditions, executorConditionCACertSecretFound))
+	require.True(t, meta.IsStatusConditionTrue(status.Conditions, executorConditionReady))
+
+	// Executors that don't create deployments don't depend on anything
+	status = &spinv1alpha1.SpinAppExecutorStatus{}
+	require.NoError(t, observeExecutorStatus(ctx, c, status, &spinv1alpha1.SpinAppExecutorSpec{}, 1, 1, nil))
+	require.Len(t, status.Conditions, 1)
+	require.True(t, meta.IsStatusConditionTrue(status.Conditions, executorConditionReady))
+}
+
+func TestSpinAppExecutorUpdateStatus(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	app := func(name, namespace, executor string) *spinv1alpha1.SpinApp {
+		return &spinv1alpha1.SpinApp{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       spinv1alpha1.SpinAppSpec{Executor: executor},
+		}
+	}
+
+	executor := testContainerdShimSpinExecutor()
+	r := &SpinAppExecutorReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&spinv1alpha1.SpinAppExecutor{}).
+			WithIndex(&spinv1alpha1.SpinApp{}, spinAppExecutorKey, func(obj client.Object) []string {
+				return []string{obj.(*spinv1alpha1.SpinApp).Spec.Executor}
+			}).
+			WithObjects(
+				executor,
+				app("app-a", "default", "test-executor"),
+				app("app-b", "default", "test-executor"),
+				app("app-c", "default", "another-executor"),
+				app("app-d", "other", "test-executor"),
+			).Build(),
+		Scheme: scheme,
+	}
+
+	ctx := context.Background()
+	require.NoError(t, r.updateStatus(ctx, executor))
+
+	var updated spinv1alpha1.SpinAppExecutor
+	require.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(executor), &updated))
+	require.Equal(t, int32(2), updated.Status.AppCount)
+	require.True(t, meta.IsStatusConditionFalse(updated.Status.Conditions, executorConditionRuntimeClassFound))
+	require.True(t, meta.IsStatusConditionFalse(updated.Status.Conditions, executorConditionReady))
+}
@@ -0,0 +1,242 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/generics"
+)
+
+// spinAppConditionHTTPRouteReady reports whether the Gateway API HTTPRoute of a
+// SpinApp exposed through one could be applied.
+const spinAppConditionHTTPRouteReady = "HTTPRouteReady"
+
+// httpRouteReadyCondition derives the HTTPRouteReady condition of a SpinApp
+// whose HTTPRoute was reconciled. The warning event for a missing Gateway API
+// installation is emitted when the condition changes, rather than on every
+// reconcile while the Gateway API isn't installed.
+func httpRouteReadyCondition(gatewayAPIInstalled bool) metav1.Condition {
+	if !gatewayAPIInstalled {
+		return metav1.Condition{
+			Type:    spinAppConditionHTTPRouteReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  "GatewayAPINotInstalled",
+			Message: "spec.exposure.httpRoute is set but the gateway.networking.k8s.io/v1 HTTPRoute CRD is not installed, the app will not be exposed through a Gateway",
+		}
+	}
+
+	return metav1.Condition{
+		Type:    spinAppConditionHTTPRouteReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  "HTTPRouteApplied",
+		Message: "Applied the HTTPRoute",
+	}
+}
+
+// errGatewayAPINotInstalled is returned when an app is exposed with an
+// HTTPRoute but the Gateway API isn't installed in the cluster.
+var errGatewayAPINotInstalled = errors.New("the Gateway API is not installed")
+
+// httpRouteGVK is the Gateway API HTTPRoute kind. The Gateway API is an
+// optional dependency, so HTTPRoutes are handled as unstructured objects.
+var httpRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}
+
+// exposedThroughHTTPRoute returns whether a SpinApp is exposed through a
+// Gateway API HTTPRoute.
+func exposedThroughHTTPRoute(app *spinv1alpha1.SpinApp) bool {
+	return app.Spec.Exposure != nil && app.Spec.Exposure.HTTPRoute != nil
+}
+
+// constructIngress builds a networkingv1.Ingress that routes the configured
+// host and paths to the service of a SpinApp.
+func constructIngress(app *spinv1alpha1.SpinApp) *networkingv1.Ingress {
+	config := app.Spec.Exposure.Ingress
+
+	backend := networkingv1.IngressBackend{
+		Service: &networkingv1.IngressServiceBackend{
+			Name: app.Name,
+			Port: networkingv1.ServiceBackendPort{Number: servicePort(app)},
+		},
+	}
+
+	paths := config.Paths
+	if len(paths) == 0 {
+		paths = []spinv1alpha1.IngressPath{{Path: "/"}}
+	}
+	var httpPaths []networkingv1.HTTPIngressPath
+	for _, path := range paths {
+		pathType := path.PathType
+		if pathType == nil {
+			pathType = generics.Ptr(networkingv1.PathTypePrefix)
+		}
+		httpPaths = append(httpPaths, networkingv1.HTTPIngressPath{
+			Path:     path.Path,
+			PathType: pathType,
+			Backend:  backend,
+		})
+	}
+
+	ingress := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Ingress",
+			APIVersion: "networking.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        app.Name,
+			Namespace:   app.Namespace,
+			Labels:      constructAppLabels(app),
+			Annotations: config.Annotations,
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: config.IngressClassName,
+			Rules: []networkingv1.IngressRule{{
+				Host: config.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{Paths: httpPaths},
+				},
+			}},
+		},
+	}
+
+	if config.TLSSecretName != "" {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{{
+			Hosts:      []string{config.Host},
+			SecretName: config.TLSSecretName,
+		}}
+	}
+
+	return ingress
+}
+
+// constructHTTPRoute builds a Gateway API HTTPRoute that attaches to the
+// configured Gateways and routes the configured hostnames and paths to the
+// service of a SpinApp.
+func constructHTTPRoute(app *spinv1alpha1.SpinApp) *unstructured.Unstructured {
+	config := app.Spec.Exposure.HTTPRoute
+
+	var parentRefs []interface{}
+	for _, ref := range config.ParentRefs {
+		parentRef := map[string]interface{}{"name": ref.Name}
+		if ref.Namespace != "" {
+			parentRef["namespace"] = ref.Namespace
+		}
+		if ref.SectionName != "" {
+			parentRef["sectionName"] = ref.SectionName
+		}
+		parentRefs = append(parentRefs, parentRef)
+	}
+
+	paths := config.Paths
+	if len(paths) == 0 {
+		paths = []spinv1alpha1.HTTPRoutePath{{Value: "/"}}
+	}
+	var matches []interface{}
+	for _, path := range paths {
+		matchType := path.Type
+		if matchType == "" {
+			matchType = "PathPrefix"
+		}
+		matches = append(matches, map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":  matchType,
+				"value": path.Value,
+			},
+		})
+	}
+
+	spec := map[string]interface{}{
+		"parentRefs": parentRefs,
+		"rules": []interface{}{
+			map[string]interface{}{
+				"matches":     matches,
+				"backendRefs": httpRouteBackendRefs(app),
+			},
+		},
+	}
+	if len(config.Hostnames) > 0 {
+		hostnames := make([]interface{}, 0, len(config.Hostnames))
+		for _, hostname := range config.Hostnames {
+			hostnames = append(hostnames, hostname)
+		}
+		spec["hostnames"] = hostnames
+	}
+
+	route := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	route.SetGroupVersionKind(httpRouteGVK)
+	route.SetName(app.Name)
+	route.SetNamespace(app.Namespace)
+	route.SetLabels(constructAppLabels(app))
+	route.SetAnnotations(config.Annotations)
+
+	return route
+}
+
+// exposureURL returns the URL a SpinApp is exposed at, based on the first host
+// and path of its Ingress, or otherwise its HTTPRoute. An empty string is
+// returned when the app isn't exposed or the URL can't be determined, e.g an
+// HTTPRoute without hostnames or a wildcard host.
+func exposureURL(app *spinv1alpha1.SpinApp) string {
+	exposure := app.Spec.Exposure
+	if exposure == nil {
+		return ""
+	}
+
+	if ingress := exposure.Ingress; ingress != nil && !strings.HasPrefix(ingress.Host, "*") {
+		scheme := "http"
+		if ingress.TLSSecretName != "" {
+			scheme = "https"
+		}
+		path := "/"
+		if len(ingress.Paths) > 0 {
+			path = ingress.Paths[0].Path
+		}
+		return fmt.Sprintf("%s://%s%s", scheme, ingress.Host, path)
+	}
+
+	if route := exposure.HTTPRoute; route != nil && len(route.Hostnames) > 0 && !strings.HasPrefix(route.Hostnames[0], "*") {
+		// TLS is configured on the Gateway, which we don't inspect
+		path := "/"
+		if len(route.Paths) > 0 {
+			path = route.Paths[0].Value
+		}
+		return fmt.Sprintf("http://%s%s", route.Hostnames[0], path)
+	}
+
+	return ""
+}
+
+// httpRouteBackendRefs returns the backends of the HTTPRoute of a SpinApp. The
+// canary Service gets the weight of the current step of a canary rollout that
+// splits traffic through the HTTPRoute.
+func httpRouteBackendRefs(app *spinv1alpha1.SpinApp) []interface{} {
+	port := int64(servicePort(app))
+	if !usesCanaryService(app) {
+		return []interface{}{
+			map[string]interface{}{
+				"name": app.Name,
+				"port": port,
+			},
+		}
+	}
+
+	weight := int64(canaryWeight(app, app.Status.Rollout))
+	return []interface{}{
+		map[string]interface{}{
+			"name":   app.Name,
+			"port":   port,
+			"weight": 100 - weight,
+		},
+		map[string]interface{}{
+			"name":   canaryName(app),
+			"port":   port,
+			"weight": weight,
+		},
+	}
+}
@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/generics"
+)
+
+func TestConstructIngress(t *testing.T) {
+	t.Parallel()
+
+	app := minimalSpinApp()
+	app.Spec.Exposure = &spinv1alpha1.Exposure{
+		Ingress: &spinv1alpha1.IngressExposure{
+			IngressClassName: generics.Ptr("nginx"),
+			Host:             "app.example.com",
+			Annotations:      map[string]string{"nginx.ingress.kubernetes.io/ssl-redirect": "true"},
+		},
+	}
+
+	ingress := constructIngress(app)
+	require.Equal(t, app.Name, ingress.Name)
+	require.Equal(t, app.Namespace, ingress.Namespace)
+	require.Equal(t, "true", ingress.Annotations["nginx.ingress.kubernetes.io/ssl-redirect"])
+	require.Equal(t, generics.Ptr("nginx"), ingress.Spec.IngressClassName)
+	require.Empty(t, ingress.Spec.TLS)
+	require.Len(t, ingress.Spec.Rules, 1)
+	require.Equal(t, "app.example.com", ingress.Spec.Rules[0].Host)
+
+	// Everything is routed to the app by default
+	paths := ingress.Spec.Rules[0].HTTP.Paths
+	require.Len(t, paths, 1)
+	require.Equal(t, "/", paths[0].Path)
+	require.Equal(t, generics.Ptr(networkingv1.PathTypePrefix), paths[0].PathType)
+	require.Equal(t, app.Name, paths[0].Backend.Service.Name)
+	require.Equal(t, int32(80), paths[0].Backend.Service.Port.Number)
+
+	// The backend follows the port of the service
+	app.Spec.Service = &spinv1alpha1.ServiceConfig{Port: generics.Ptr(int32(8080))}
+	app.Spec.Exposure.Ingress.TLSSecretName = "app-tls"
+	app.Spec.Exposure.Ingress.Paths = []spinv1alpha1.IngressPath{
+		{Path: "/api"},
+		{Path: "/healthz", PathType: generics.Ptr(networkingv1.PathTypeExact)},
+	}
+
+	ingress = constructIngress(app)
+	require.Equal(t, []networkingv1.IngressTLS{{Hosts: []string{"app.example.com"}, SecretName: "app-tls"}}, ingress.Spec.TLS)
+	paths = ingress.Spec.Rules[0].HTTP.Paths
+	require.Len(t, paths, 2)
+	require.Equal(t, generics.Ptr(networkingv1.PathTypePrefix), paths[0].PathType)
+	require.Equal(t, "/healthz", paths[1].Path)
+	require.Equal(t, generics.Ptr(networkingv1.PathTypeExact), paths[1].PathType)
+	require.Equal(t, int32(8080), paths[1].Backend.Service.Port.Number)
+}
+
+func TestConstructHTTPRoute(t *testing.T) {
+	t.Parallel()
+
+	app := minimalSpinApp()
+	app.Spec.Exposure = &spinv1alpha1.Exposure{
+		HTTPRoute: &spinv1alpha1.HTTPRouteExposure{
+			ParentRefs: []spinv1alpha1.GatewayParentRef{
+				{Name: "public"},
+				{Name: "internal", Namespace: "gateways", SectionName: "https"},
+			},
+			Hostnames: []string{"app.example.com"},
+			Paths:     []spinv1alpha1.HTTPRoutePath{{Value: "/api"}, {Value: "/healthz", Type: "Exact"}},
+		},
+	}
+
+	route := constructHTTPRoute(app)
+	require.Equal(t, httpRouteGVK, route.GroupVersionKind())
+	require.Equal(t, app.Name, route.GetName())
+	require.Equal(t, app.Namespace, route.GetNamespace())
+
+	spec := route.Object["spec"].(map[string]interface{})
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"name": "public"},
+		map[string]interface{}{"name": "internal", "namespace": "gateways", "sectionName": "https"},
+	}, spec["parentRefs"])
+	require.Equal(t, []interface{}{"app.example.com"}, spec["hostnames"])
+	require.Equal(t, []interface{}{
+		map[string]interface{}{
+			"matches": []interface{}{
+				map[string]interface{}{"path": map[string]interface{}{"type": "PathPrefix", "value": "/api"}},
+				map[string]interface{}{"path": map[string]interface{}{"type": "Exact", "value": "/healthz"}},
+			},
+			"backendRefs": []interface{}{
+				map[string]interface{}{"name": app.Name, "port": int64(80)},
+			},
+		},
+	}, spec["rules"])
+
+	// Hostnames default to those of the Gateway listeners
+	app.Spec.Exposure.HTTPRoute.Hostnames = nil
+	route = constructHTTPRoute(app)
+	require.NotContains(t, route.Object["spec"], "hostnames")
+}
+
+func TestExposureURL(t *testing.T) {
+	t.Parallel()
+
+	app := minimalSpinApp()
+	require.Empty(t, exposureURL(app))
+
+	app.Spec.Exposure = &spinv1alpha1.Exposure{
+		HTTPRoute: &spinv1alpha1.HTTPRouteExposure{Hostnames: []string{"route.example.com"}},
+	}
+	require.Equal(t, "http://route.example.com/", exposureURL(app))
+
+	app.Spec.Exposure.HTTPRoute.Hostnames = []string{"*.example.com"}
+	require.Empty(t, exposureURL(app))
+
+	// The Ingress takes precedence
+	app.Spec.Exposure.Ingress = &spinv1alpha1.IngressExposure{
+		Host:          "app.example.com",
+		Paths:         []spinv1alpha1.IngressPath{{Path: "/api"}},
+		TLSSecretName: "app-tls",
+	}
+	require.Equal(t, "https://app.example.com/api", exposureURL(app))
+}
+
+func TestReconcileExposure_Delete(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	app := minimalSpinApp()
+	app.UID = "my-app-uid"
+
+	owned := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: app.Name, Namespace: app.Namespace},
+	}
+	require.NoError(t, controllerutil.SetControllerReference(app, owned, scheme))
+
+	recorder := record.NewFakeRecorder(1)
+	r := &SpinAppReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(owned).Build(),
+		Scheme:   scheme,
+		Recorder: recorder,
+	}
+
+	ctx := context.Background()
+
+	// Without exposure the Ingress is removed, and missing HTTPRoutes are
+	// ignored when the Gateway API isn't installed
+	require.NoError(t, r.reconcileIngress(ctx, app))
+	require.NoError(t, r.reconcileHTTPRoute(ctx, app))
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(owned), &networkingv1.Ingress{})
+	require.True(t, apierrors.IsNotFound(err))
+
+	app.Spec.Exposure = &spinv1alpha1.Exposure{
+		HTTPRoute: &spinv1alpha1.HTTPRouteExposure{
+			ParentRefs: []spinv1alpha1.GatewayParentRef{{Name: "gateway"}},
+		},
+	}
+	// The missing installation is reported through the status of the app
+	err = r.reconcileHTTPRoute(ctx, app)
+	require.ErrorIs(t, err, errGatewayAPINotInstalled)
+	require.Empty(t, recorder.Events)
+}
+
+func TestHTTPRouteReadyCondition(t *testing.T) {
+	t.Parallel()
+
+	recorder := record.NewFakeRecorder(2)
+	r := &SpinAppReconciler{Recorder: recorder}
+
+	executor := &spinv1alpha1.SpinAppExecutor{Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true}}
+	app := minimalSpinApp()
+	app.Spec.Exposure = &spinv1alpha1.Exposure{
+		HTTPRoute: &spinv1alpha1.HTTPRouteExposure{
+			ParentRefs: []spinv1alpha1.GatewayParentRef{{Name: "gateway"}},
+		},
+	}
+
+	observed := &statusObservation{Executor: executor, GatewayAPIInstalled: generics.Ptr(false)}
+	status := computeStatus(app, observed)
+	condition := meta.FindStatusCondition(status.Conditions, spinAppConditionHTTPRouteReady)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, "GatewayAPINotInstalled", condition.Reason)
+
+	// The warning is only emitted when the Gateway API is first found missing,
+	// not on every reconcile that checks back whether it was installed
+	r.recordStatusEvents(app, &app.Status, &status)
+	require.Contains(t, <-recorder.Events, "GatewayAPINotInstalled")
+	app.Status = status
+	status = computeStatus(app, observed)
+	r.recordStatusEvents(app, &app.Status, &status)
+	require.Empty(t, recorder.Events)
+
+	// Installing the Gateway API clears the condition
+	app.Status = status
+	status = computeStatus(app, &statusObservation{Executor: executor, GatewayAPIInstalled: generics.Ptr(true)})
+	require.True(t, meta.IsStatusConditionTrue(status.Conditions, spinAppConditionHTTPRouteReady))
+	r.recordStatusEvents(app, &app.Status, &status)
+	require.Empty(t, recorder.Events)
+
+	// The condition is removed when the app is no longer exposed through an
+	// HTTPRoute
+	app.Status = status
+	app.Spec.Exposure = nil
+	status = computeStatus(app, &statusObservation{Executor: executor})
+	require.Nil(t, meta.FindStatusCondition(status.Conditions, spinAppConditionHTTPRouteReady))
+}
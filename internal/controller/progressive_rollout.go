@@ -0,0 +1,359 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/pkg/spinapp"
+)
+
+// canaryName returns the name of the canary Deployment and Service of a
+// SpinApp.
+func canaryName(app *spinv1alpha1.SpinApp) string {
+	return app.Name + "-canary"
+}
+
+// rolloutInProgress returns whether a progressive rollout of a SpinApp is in
+// progress, which is when it has a canary Deployment.
+func rolloutInProgress(app *spinv1alpha1.SpinApp) bool {
+	if app.Spec.Rollout == nil || app.Status.Rollout == nil {
+		return false
+	}
+	phase := app.Status.Rollout.Phase
+	return phase == spinv1alpha1.RolloutPhaseProgressing || phase == spinv1alpha1.RolloutPhasePromoting
+}
+
+// currentCanaryStep returns the canary step of a rollout that is in progress.
+// The step is clamped to the configured steps, which may have been edited
+// since the rollout started.
+func currentCanaryStep(canary *spinv1alpha1.CanaryRollout, status *spinv1alpha1.RolloutStatus) spinv1alpha1.CanaryStep {
+	step := 0
+	if status.CurrentStep != nil {
+		step = int(*status.CurrentStep)
+	}
+	step = min(max(step, 0), len(canary.Steps)-1)
+	return canary.Steps[step]
+}
+
+// canaryWeight returns the percentage of traffic that should be sent to the
+// canary Deployment of a rollout that is in progress.
+func canaryWeight(app *spinv1alpha1.SpinApp, status *spinv1alpha1.RolloutStatus) int32 {
+	if app.Spec.Rollout.Canary == nil || len(app.Spec.Rollout.Canary.Steps) == 0 {
+		return 100
+	}
+	return currentCanaryStep(app.Spec.Rollout.Canary, status).Weight
+}
+
+// rolloutPause returns how long the canary pods must be ready for in the
+// current step of a rollout before it moves on.
+func rolloutPause(app *spinv1alpha1.SpinApp, status *spinv1alpha1.RolloutStatus) time.Duration {
+	var pause *metav1.Duration
+	if app.Spec.Rollout.Canary != nil && len(app.Spec.Rollout.Canary.Steps) > 0 {
+		pause = currentCanaryStep(app.Spec.Rollout.Canary, status).Pause
+	} else if app.Spec.Rollout.BlueGreen != nil {
+		pause = app.Spec.Rollout.BlueGreen.PromotionPause
+	}
+	if pause == nil {
+		return 0
+	}
+	return pause.Duration
+}
+
+// rolloutReplicas returns the number of replicas of the stable and canary
+// Deployments of a SpinApp in the given rollout state.
+//
+// Canary replicas are the weight of the current step applied to the replicas
+// of the app. When traffic is split by replica ratio the stable Deployment is
+// scaled down by the same amount, otherwise it keeps every replica.
+func rolloutReplicas(app *spinv1alpha1.SpinApp, status *spinv1alpha1.RolloutStatus) (int32, int32) {
+	replicas := app.Spec.Replicas
+	if app.Spec.Rollout == nil || status == nil ||
+		(status.Phase != spinv1alpha1.RolloutPhaseProgressing && status.Phase != spinv1alpha1.RolloutPhasePromoting) {
+		return replicas, 0
+	}
+
+	if app.Spec.Rollout.Canary == nil {
+		return replicas, replicas
+	}
+
+	// Rounded up, so that every step runs at least one canary pod
+	canaryReplicas := (replicas*canaryWeight(app, status) + 99) / 100
+
+	if status.Phase == spinv1alpha1.RolloutPhasePromoting || status.TrafficRouting == spinv1alpha1.RolloutTrafficRoutingHTTPRoute {
+		return replicas, canaryReplicas
+	}
+	return replicas - canaryReplicas, canaryReplicas
+}
+
+// serviceRolloutTrack returns the rollout track that the Service of a SpinApp
+// should send traffic to, or an empty string for the pods of every track.
+func serviceRolloutTrack(app *spinv1alpha1.SpinApp) string {
+	if !rolloutInProgress(app) {
+		return ""
+	}
+
+	status := app.Status.Rollout
+	switch {
+	case app.Spec.Rollout.BlueGreen != nil && status.Phase == spinv1alpha1.RolloutPhasePromoting:
+		// Traffic is switched to the new pods while the stable Deployment is
+		// updated to the new image.
+		return spinapp.RolloutTrackCanary
+	case app.Spec.Rollout.BlueGreen != nil, status.TrafficRouting == spinv1alpha1.RolloutTrafficRoutingHTTPRoute:
+		return spinapp.RolloutTrackStable
+	default:
+		return ""
+	}
+}
+
+// rolloutTrackSelector returns the labels that select the pods of a rollout
+// track of a SpinApp, or the pods of every track for an empty track.
+func rolloutTrackSelector(app *spinv1alpha1.SpinApp, track string) map[string]string {
+	switch track {
+	case spinapp.RolloutTrackStable:
+		statusKey, statusValue := spinapp.ConstructStatusReadyLabel(app.Name)
+		return map[string]string{statusKey: statusValue}
+	case spinapp.RolloutTrackCanary:
+		return constructCanaryPodSelectorLabels(app)
+	default:
+		return constructAppLabels(app)
+	}
+}
+
+// constructCanaryPodSelectorLabels returns the labels that select the pods of
+// the canary Deployment of a SpinApp.
+func constructCanaryPodSelectorLabels(app *spinv1alpha1.SpinApp) map[string]string {
+	statusKey, statusValue := spinapp.ConstructStatusReadyLabel(canaryName(app))
+	return map[string]string{
+		spinapp.NameLabelKey: app.Name,
+		statusKey:            statusValue,
+	}
+}
+
+// usesCanaryService returns whether a SpinApp needs a Service for its canary
+// pods, which is when the HTTPRoute of the app splits traffic.
+func usesCanaryService(app *spinv1alpha1.SpinApp) bool {
+	return rolloutInProgress(app) && app.Spec.Rollout.Canary != nil &&
+		app.Status.Rollout.TrafficRouting == spinv1alpha1.RolloutTrafficRoutingHTTPRoute
+}
+
+// deploymentComplete returns whether a Deployment runs the given image with
+// every one of the given number of replicas updated and available.
+func deploymentComplete(deployment *appsv1.Deployment, image string, replicas int32) bool {
+	if deployment == nil || deployment.Annotations[spinapp.ImageAnnotationKey] != image ||
+		deployment.Status.ObservedGeneration < deployment.Generation {
+		return false
+	}
+	if deployment.Spec.Replicas != nil && *deployment.Spec.Replicas != replicas {
+		return false
+	}
+
+	status := deployment.Status
+	return status.Replicas == replicas && status.UpdatedReplicas == replicas && status.AvailableReplicas == replicas
+}
+
+// advanceRollout returns the next state of the progressive rollout of a
+// SpinApp based on its stable and canary Deployments, either of which may be
+// nil. nil is returned for apps without a progressive rollout.
+//
+// A rollout is started when spec.image differs from the stable image. Each
+// step waits for every canary pod to be available, followed by the pause of
+// the step. After the last step the new image is promoted: it becomes the
+// stable image, and the rollout completes once the stable Deployment has
+// rolled out. Until then, a rollout is aborted when spec.rollout.abort is set,
+// or when the canary Deployment exceeds its progress deadline.
+func advanceRollout(app *spinv1alpha1.SpinApp, stable, canary *appsv1.Deployment,
+	httpRouteAvailable bool, now time.Time) *spinv1alpha1.RolloutStatus {
+	if app.Spec.Rollout == nil {
+		return nil
+	}
+
+	status := app.Status.Rollout.DeepCopy()
+	if status == nil || status.StableImage == "" {
+		return &spinv1alpha1.RolloutStatus{
+			Phase:       spinv1alpha1.RolloutPhaseStable,
+			StableImage: app.Spec.Image,
+			Message:     fmt.Sprintf("Running image %s", app.Spec.Image),
+		}
+	}
+
+	inProgress := status.Phase == spinv1alpha1.RolloutPhaseProgressing || status.Phase == spinv1alpha1.RolloutPhasePromoting
+	resetCanary := func(phase spinv1alpha1.RolloutPhase, message string) {
+		status.Phase = phase
+		status.CanaryImage = ""
+		status.CurrentStep = nil
+		status.StepReadyTime = nil
+		status.TrafficRouting = ""
+		status.Message = message
+	}
+
+	// The image being promoted is already the stable image, and is past the
+	// point where the rollout can be aborted.
+	promoting := status.Phase == spinv1alpha1.RolloutPhasePromoting && app.Spec.Image == status.CanaryImage
+
+	switch {
+	case promoting:
+	case app.Spec.Image == status.StableImage:
+		// Nothing to roll out, or the change was reverted
+		resetCanary(spinv1alpha1.RolloutPhaseStable, fmt.Sprintf("Running image %s", status.StableImage))
+		return status
+	case app.Spec.Rollout.Abort:
+		if inProgress {
+			resetCanary(spinv1alpha1.RolloutPhaseAborted, fmt.Sprintf("Rollout of image %s was aborted", status.CanaryImage))
+		} else if status.Phase == spinv1alpha1.RolloutPhaseStable {
+			resetCanary(spinv1alpha1.RolloutPhaseAborted,
+				fmt.Sprintf("Rollout of image %s is on hold because spec.rollout.abort is set", app.Spec.Image))
+		}
+		return status
+	case !inProgress && app.Spec.Image == status.AbortedImage:
+		return status
+	case !inProgress || app.Spec.Image != status.CanaryImage:
+		// Images that change during a rollout restart it
+		resetCanary(spinv1alpha1.RolloutPhaseProgressing, fmt.Sprintf("Rolling out image %s", app.Spec.Image))
+		status.CanaryImage = app.Spec.Image
+		if app.Spec.Rollout.Canary != nil {
+			status.CurrentStep = new(int32)
+			status.TrafficRouting = spinv1alpha1.RolloutTrafficRoutingReplicaRatio
+			if httpRouteAvailable {
+				status.TrafficRouting = spinv1alpha1.RolloutTrafficRoutingHTTPRoute
+			}
+		}
+		return status
+	}
+
+	if status.Phase == spinv1alpha1.RolloutPhasePromoting {
+		stableReplicas, _ := rolloutReplicas(app, status)
+		if deploymentComplete(stable, status.StableImage, stableReplicas) {
+			resetCanary(spinv1alpha1.RolloutPhaseStable, fmt.Sprintf("Rolled out image %s", status.StableImage))
+		}
+		return status
+	}
+
+	if canary != nil && rolloutFailedCondition(canary).Status == metav1.ConditionTrue {
+		image := status.CanaryImage
+		resetCanary(spinv1alpha1.RolloutPhaseAborted,
+			fmt.Sprintf("Rollout of image %s was aborted because the canary Deployment exceeded its progress deadline", image))
+		status.AbortedImage = image
+		return status
+	}
+
+	_, canaryReplicas := rolloutReplicas(app, status)
+	if !deploymentComplete(canary, status.CanaryImage, canaryReplicas) {
+		// The pause of the step restarts when a canary pod becomes unready
+		status.StepReadyTime = nil
+		return status
+	}
+	if status.StepReadyTime == nil {
+		status.StepReadyTime = &metav1.Time{Time: now}
+	}
+	if now.Before(status.StepReadyTime.Add(rolloutPause(app, status))) {
+		return status
+	}
+
+	if app.Spec.Rollout.Canary != nil && status.CurrentStep != nil &&
+		int(*status.CurrentStep) < len(app.Spec.Rollout.Canary.Steps)-1 {
+		*status.CurrentStep++
+		status.StepReadyTime = nil
+		status.Message = fmt.Sprintf("Rolling out image %s to %d%% of traffic", status.CanaryImage, canaryWeight(app, status))
+		return status
+	}
+
+	status.Phase = spinv1alpha1.RolloutPhasePromoting
+	status.StableImage = status.CanaryImage
+	status.StepReadyTime = nil
+	status.Message = fmt.Sprintf("Promoting image %s", status.CanaryImage)
+	return status
+}
+
+// rolloutRequeueAfter returns how long to wait before the pause of the current
+// step of a rollout ends, or 0 when the rollout isn't pausing.
+func rolloutRequeueAfter(app *spinv1alpha1.SpinApp, now time.Time) time.Duration {
+	if !rolloutInProgress(app) || app.Status.Rollout.StepReadyTime == nil {
+		return 0
+	}
+
+	remaining := app.Status.Rollout.StepReadyTime.Add(rolloutPause(app, app.Status.Rollout)).Sub(now)
+	return max(remaining, 0)
+}
+
+// constructRolloutDeployments builds the stable Deployment of a SpinApp, and
+// its canary Deployment when a progressive rollout is in progress.
+func constructRolloutDeployments(ctx context.Context, app *spinv1alpha1.SpinApp, config *spinv1alpha1.ExecutorDeploymentConfig,
+	generatedRuntimeConfigSecretName, caSecretName string, scheme *runtime.Scheme) (*appsv1.Deployment, *appsv1.Deployment, error) {
+	if app.Spec.Rollout == nil || app.Status.Rollout == nil {
+		stable, err := constructDeployment(ctx, app, config, generatedRuntimeConfigSecretName, caSecretName, scheme)
+		return stable, nil, err
+	}
+
+	status := app.Status.Rollout
+	stableReplicas, canaryReplicas := rolloutReplicas(app, status)
+
+	construct := func(image string, replicas int32) (*appsv1.Deployment, error) {
+		trackApp := app.DeepCopy()
+		trackApp.Spec.Image = image
+		trackApp.Spec.Replicas = replicas
+
+		deployment, err := constructDeployment(ctx, trackApp, config, generatedRuntimeConfigSecretName, caSecretName, scheme)
+		if err != nil {
+			return nil, err
+		}
+
+		deployment.Annotations = maps.Clone(deployment.Annotations)
+		deployment.Annotations[spinapp.ImageAnnotationKey] = image
+		return deployment, nil
+	}
+
+	// The pod template of the stable Deployment is left as is, so that starting
+	// a rollout doesn't roll the stable pods.
+	stable, err := construct(status.StableImage, stableReplicas)
+	if err != nil || !rolloutInProgress(app) {
+		return stable, nil, err
+	}
+
+	canary, err := construct(status.CanaryImage, canaryReplicas)
+	if err != nil {
+		return nil, nil, err
+	}
+	canary.Name = canaryName(app)
+	// The canary pods carry the ready label of the canary instead of the one of
+	// the app, so the selectors of the two Deployments don't overlap.
+	statusKey, _ := spinapp.ConstructStatusReadyLabel(app.Name)
+	delete(canary.Spec.Template.Labels, statusKey)
+	maps.Copy(canary.Spec.Template.Labels, constructCanaryPodSelectorLabels(app))
+	canary.Spec.Selector.MatchLabels = constructCanaryPodSelectorLabels(app)
+
+	return stable, canary, nil
+}
+
+// constructCanaryService builds the Service that the HTTPRoute of a SpinApp
+// sends the canary share of traffic to.
+func constructCanaryService(app *spinv1alpha1.SpinApp) *corev1.Service {
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      canaryName(app),
+			Namespace: app.Namespace,
+			Labels:    constructAppLabels(app),
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{{
+				Protocol:   corev1.ProtocolTCP,
+				TargetPort: intstr.FromString(spinapp.HTTPPortName),
+				Port:       servicePort(app),
+			}},
+			Selector: rolloutTrackSelector(app, spinapp.RolloutTrackCanary),
+		},
+	}
+}
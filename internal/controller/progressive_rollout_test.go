@@ -0,0 +1,339 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/generics"
+	"github.com/spinkube/spin-operator/pkg/spinapp"
+)
+
+// completeDeployment returns a Deployment that finished rolling out image with
+// the given number of replicas.
+func completeDeployment(image string, replicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Generation:  1,
+			Annotations: map[string]string{spinapp.ImageAnnotationKey: image},
+		},
+		Spec: appsv1.DeploymentSpec{Replicas: generics.Ptr(replicas)},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 1,
+			Replicas:           replicas,
+			UpdatedReplicas:    replicas,
+			AvailableReplicas:  replicas,
+		},
+	}
+}
+
+func TestAdvanceRollout_Canary(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	app := minimalSpinApp()
+	app.Spec.Replicas = 4
+	app.Spec.Image = "app:v1"
+	app.Spec.Rollout = &spinv1alpha1.ProgressiveRollout{
+		Canary: &spinv1alpha1.CanaryRollout{
+			Steps: []spinv1alpha1.CanaryStep{
+				{Weight: 25, Pause: &metav1.Duration{Duration: time.Minute}},
+				{Weight: 50},
+			},
+		},
+	}
+
+	// The current image becomes the stable image
+	app.Status.Rollout = advanceRollout(app, nil, nil, false, now)
+	require.Equal(t, spinv1alpha1.RolloutPhaseStable, app.Status.Rollout.Phase)
+	require.Equal(t, "app:v1", app.Status.Rollout.StableImage)
+	require.False(t, rolloutInProgress(app))
+
+	// Changing the image starts a rollout
+	app.Spec.Image = "app:v2"
+	app.Status.Rollout = advanceRollout(app, completeDeployment("app:v1", 4), nil, false, now)
+	require.Equal(t, spinv1alpha1.RolloutPhaseProgressing, app.Status.Rollout.Phase)
+	require.Equal(t, "app:v2", app.Status.Rollout.CanaryImage)
+	require.Equal(t, generics.Ptr(int32(0)), app.Status.Rollout.CurrentStep)
+	require.Equal(t, spinv1alpha1.RolloutTrafficRoutingReplicaRatio, app.Status.Rollout.TrafficRouting)
+	stableReplicas, canaryReplicas := rolloutReplicas(app, app.Status.Rollout)
+	require.Equal(t, int32(3), stableReplicas)
+	require.Equal(t, int32(1), canaryReplicas)
+
+	// The pause starts once the canary is available
+	app.Status.Rollout = advanceRollout(app, completeDeployment("app:v1", 3), nil, false, now)
+	require.Nil(t, app.Status.Rollout.StepReadyTime)
+	app.Status.Rollout = advanceRollout(app, completeDeployment("app:v1", 3), completeDeployment("app:v2", 1), false, now)
+	require.NotNil(t, app.Status.Rollout.StepReadyTime)
+	require.Equal(t, generics.Ptr(int32(0)), app.Status.Rollout.CurrentStep)
+	require.Equal(t, time.Minute, rolloutRequeueAfter(app, now))
+
+	// The next step starts after the pause
+	app.Status.Rollout = advanceRollout(app, completeDeployment("app:v1", 3), completeDeployment("app:v2", 1), false, now.Add(time.Minute))
+	require.Equal(t, generics.Ptr(int32(1)), app.Status.Rollout.CurrentStep)
+	require.Nil(t, app.Status.Rollout.StepReadyTime)
+	stableReplicas, canaryReplicas = rolloutReplicas(app, app.Status.Rollout)
+	require.Equal(t, int32(2), stableReplicas)
+	require.Equal(t, int32(2), canaryReplicas)
+
+	// The new image is promoted after the last step
+	app.Status.Rollout = advanceRollout(app, completeDeployment("app:v1", 2), completeDeployment("app:v2", 2), false, now)
+	require.Equal(t, spinv1alpha1.RolloutPhasePromoting, app.Status.Rollout.Phase)
+	require.Equal(t, "app:v2", app.Status.Rollout.StableImage)
+	stableReplicas, canaryReplicas = rolloutReplicas(app, app.Status.Rollout)
+	require.Equal(t, int32(4), stableReplicas)
+	require.Equal(t, int32(2), canaryReplicas)
+
+	// Promotions can't be aborted
+	app.Spec.Rollout.Abort = true
+	app.Status.Rollout = advanceRollout(app, completeDeployment("app:v1", 2), completeDeployment("app:v2", 2), false, now)
+	require.Equal(t, spinv1alpha1.RolloutPhasePromoting, app.Status.Rollout.Phase)
+	app.Spec.Rollout.Abort = false
+
+	// The rollout completes once the stable Deployment runs the new image
+	app.Status.Rollout = advanceRollout(app, completeDeployment("app:v2", 4), completeDeployment("app:v2", 2), false, now)
+	require.Equal(t, spinv1alpha1.RolloutPhaseStable, app.Status.Rollout.Phase)
+	require.Empty(t, app.Status.Rollout.CanaryImage)
+	require.Nil(t, app.Status.Rollout.CurrentStep)
+	require.False(t, rolloutInProgress(app))
+}
+
+func TestAdvanceRollout_Abort(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	app := minimalSpinApp()
+	app.Spec.Replicas = 2
+	app.Spec.Image = "app:v2"
+	app.Spec.Rollout = &spinv1alpha1.ProgressiveRollout{
+		Canary: &spinv1alpha1.CanaryRollout{Steps: []spinv1alpha1.CanaryStep{{Weight: 50}}},
+	}
+	app.Status.Rollout = &spinv1alpha1.RolloutStatus{
+		Phase:       spinv1alpha1.RolloutPhaseProgressing,
+		StableImage: "app:v1",
+		CanaryImage: "app:v2",
+		CurrentStep: generics.Ptr(int32(0)),
+	}
+
+	// Aborting returns to the stable image
+	app.Spec.Rollout.Abort = true
+	app.Status.Rollout = advanceRollout(app, nil, nil, false, now)
+	require.Equal(t, spinv1alpha1.RolloutPhaseAborted, app.Status.Rollout.Phase)
+	require.Equal(t, "app:v1", app.Status.Rollout.StableImage)
+	require.Empty(t, app.Status.Rollout.CanaryImage)
+	require.False(t, rolloutInProgress(app))
+
+	// The rollout restarts when the abort is lifted
+	app.Spec.Rollout.Abort = false
+	app.Status.Rollout = advanceRollout(app, nil, nil, false, now)
+	require.Equal(t, spinv1alpha1.RolloutPhaseProgressing, app.Status.Rollout.Phase)
+
+	// A canary that exceeds its progress deadline aborts the rollout
+	canary := completeDeployment("app:v2", 0)
+	canary.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:   appsv1.DeploymentProgressing,
+		Status: corev1.ConditionFalse,
+		Reason: "ProgressDeadlineExceeded",
+	}}
+	app.Status.Rollout = advanceRollout(app, nil, canary, false, now)
+	require.Equal(t, spinv1alpha1.RolloutPhaseAborted, app.Status.Rollout.Phase)
+	require.Equal(t, "app:v2", app.Status.Rollout.AbortedImage)
+
+	// It isn't retried until the image changes
+	app.Status.Rollout = advanceRollout(app, nil, nil, false, now)
+	require.Equal(t, spinv1alpha1.RolloutPhaseAborted, app.Status.Rollout.Phase)
+	app.Spec.Image = "app:v3"
+	app.Status.Rollout = advanceRollout(app, nil, nil, false, now)
+	require.Equal(t, spinv1alpha1.RolloutPhaseProgressing, app.Status.Rollout.Phase)
+	require.Equal(t, "app:v3", app.Status.Rollout.CanaryImage)
+
+	// Reverting the image ends the rollout
+	app.Spec.Image = "app:v1"
+	app.Status.Rollout = advanceRollout(app, nil, nil, false, now)
+	require.Equal(t, spinv1alpha1.RolloutPhaseStable, app.Status.Rollout.Phase)
+
+	// Apps without a rollout have no rollout status
+	app.Spec.Rollout = nil
+	require.Nil(t, advanceRollout(app, nil, nil, false, now))
+}
+
+func TestAdvanceRollout_BlueGreen(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	app := minimalSpinApp()
+	app.Spec.Replicas = 3
+	app.Spec.Image = "app:v2"
+	app.Spec.Rollout = &spinv1alpha1.ProgressiveRollout{
+		BlueGreen: &spinv1alpha1.BlueGreenRollout{},
+	}
+	app.Status.Rollout = &spinv1alpha1.RolloutStatus{
+		Phase:       spinv1alpha1.RolloutPhaseStable,
+		StableImage: "app:v1",
+	}
+
+	app.Status.Rollout = advanceRollout(app, nil, nil, true, now)
+	require.Equal(t, spinv1alpha1.RolloutPhaseProgressing, app.Status.Rollout.Phase)
+	require.Nil(t, app.Status.Rollout.CurrentStep)
+	stableReplicas, canaryReplicas := rolloutReplicas(app, app.Status.Rollout)
+	require.Equal(t, int32(3), stableReplicas)
+	require.Equal(t, int32(3), canaryReplicas)
+	// Traffic stays on the stable image until the new one is promoted
+	require.Equal(t, spinapp.RolloutTrackStable, serviceRolloutTrack(app))
+
+	app.Status.Rollout = advanceRollout(app, completeDeployment("app:v1", 3), completeDeployment("app:v2", 3), true, now)
+	require.Equal(t, spinv1alpha1.RolloutPhasePromoting, app.Status.Rollout.Phase)
+	require.Equal(t, spinapp.RolloutTrackCanary, serviceRolloutTrack(app))
+}
+
+func TestConstructRolloutDeployments(t *testing.T) {
+	t.Parallel()
+
+	config := &spinv1alpha1.ExecutorDeploymentConfig{RuntimeClassName: generics.Ptr("wasmtime-spin-v2")}
+	app := minimalSpinApp()
+	app.Spec.Replicas = 4
+	app.Spec.Image = "app:v2"
+
+	// Apps without a rollout only have a stable Deployment
+	stable, canary, err := constructRolloutDeployments(context.Background(), app, config, "", "", nil)
+	require.NoError(t, err)
+	require.Nil(t, canary)
+	require.Equal(t, "app:v2", stable.Spec.Template.Spec.Containers[0].Image)
+	stableLabels := stable.Spec.Template.Labels
+
+	app.Spec.Rollout = &spinv1alpha1.ProgressiveRollout{
+		Canary: &spinv1alpha1.CanaryRollout{Steps: []spinv1alpha1.CanaryStep{{Weight: 25}}},
+	}
+	app.Status.Rollout = &spinv1alpha1.RolloutStatus{
+		Phase:          spinv1alpha1.RolloutPhaseProgressing,
+		StableImage:    "app:v1",
+		CanaryImage:    "app:v2",
+		CurrentStep:    generics.Ptr(int32(0)),
+		TrafficRouting: spinv1alpha1.RolloutTrafficRoutingReplicaRatio,
+	}
+
+	stable, canary, err = constructRolloutDeployments(context.Background(), app, config, "", "", nil)
+	require.NoError(t, err)
+
+	require.Equal(t, app.Name, stable.Name)
+	require.Equal(t, "app:v1", stable.Spec.Template.Spec.Containers[0].Image)
+	require.Equal(t, "app:v1", stable.Annotations[spinapp.ImageAnnotationKey])
+	require.Equal(t, generics.Ptr(int32(3)), stable.Spec.Replicas)
+	// Starting a rollout doesn't roll the stable pods
+	require.Equal(t, stableLabels, stable.Spec.Template.Labels)
+	require.Equal(t, constructPodSelectorLabels(app), stable.Spec.Selector.MatchLabels)
+
+	require.Equal(t, canaryName(app), canary.Name)
+	require.Equal(t, "app:v2", canary.Spec.Template.Spec.Containers[0].Image)
+	require.Equal(t, "app:v2", canary.Annotations[spinapp.ImageAnnotationKey])
+	require.Equal(t, generics.Ptr(int32(1)), canary.Spec.Replicas)
+	require.Equal(t, constructCanaryPodSelectorLabels(app), canary.Spec.Selector.MatchLabels)
+	// The selectors of the stable and canary Deployments don't overlap
+	require.False(t, labels.SelectorFromSet(stable.Spec.Selector.MatchLabels).Matches(labels.Set(canary.Spec.Template.Labels)))
+	require.False(t, labels.SelectorFromSet(canary.Spec.Selector.MatchLabels).Matches(labels.Set(stable.Spec.Template.Labels)))
+	// The pods of both Deployments receive traffic through the Service of the app
+	selector := labels.SelectorFromSet(constructService(app).Spec.Selector)
+	require.True(t, selector.Matches(labels.Set(stable.Spec.Template.Labels)))
+	require.True(t, selector.Matches(labels.Set(canary.Spec.Template.Labels)))
+}
+
+func TestRolloutTrafficRouting_HTTPRoute(t *testing.T) {
+	t.Parallel()
+
+	app := minimalSpinApp()
+	app.Spec.Replicas = 4
+	app.Spec.Image = "app:v2"
+	app.Spec.Exposure = &spinv1alpha1.Exposure{
+		HTTPRoute: &spinv1alpha1.HTTPRouteExposure{
+			ParentRefs: []spinv1alpha1.GatewayParentRef{{Name: "gateway"}},
+		},
+	}
+	app.Spec.Rollout = &spinv1alpha1.ProgressiveRollout{
+		Canary: &spinv1alpha1.CanaryRollout{Steps: []spinv1alpha1.CanaryStep{{Weight: 10}}},
+	}
+	app.Status.Rollout = &spinv1alpha1.RolloutStatus{
+		Phase:       spinv1alpha1.RolloutPhaseStable,
+		StableImage: "app:v1",
+	}
+
+	app.Status.Rollout = advanceRollout(app, nil, nil, true, time.Now())
+	require.Equal(t, spinv1alpha1.RolloutTrafficRoutingHTTPRoute, app.Status.Rollout.TrafficRouting)
+	require.True(t, usesCanaryService(app))
+
+	// The stable Deployment keeps every replica
+	stableReplicas, canaryReplicas := rolloutReplicas(app, app.Status.Rollout)
+	require.Equal(t, int32(4), stableReplicas)
+	require.Equal(t, int32(1), canaryReplicas)
+
+	require.Equal(t, rolloutTrackSelector(app, spinapp.RolloutTrackStable), constructService(app).Spec.Selector)
+	require.Equal(t, constructCanaryPodSelectorLabels(app), constructCanaryService(app).Spec.Selector)
+
+	route := constructHTTPRoute(app)
+	rules := route.Object["spec"].(map[string]interface{})["rules"].([]interface{})
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"name": app.Name, "port": int64(80), "weight": int64(90)},
+		map[string]interface{}{"name": canaryName(app), "port": int64(80), "weight": int64(10)},
+	}, rules[0].(map[string]interface{})["backendRefs"])
+}
+
+func TestDeleteCanary(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	app := minimalSpinApp()
+	app.UID = "my-app-uid"
+
+	canaryDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: canaryName(app), Namespace: app.Namespace},
+	}
+	require.NoError(t, controllerutil.SetControllerReference(app, canaryDeployment, scheme))
+	canaryService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: canaryName(app), Namespace: app.Namespace},
+	}
+	require.NoError(t, controllerutil.SetControllerReference(app, canaryService, scheme))
+
+	r := &SpinAppReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(canaryDeployment, canaryService).Build(),
+		Scheme: scheme,
+	}
+
+	ctx := context.Background()
+
+	// Nothing is removed while a rollout splits traffic through the canary
+	app.Spec.Rollout = &spinv1alpha1.ProgressiveRollout{
+		Canary: &spinv1alpha1.CanaryRollout{Steps: []spinv1alpha1.CanaryStep{{Weight: 10}}},
+	}
+	app.Status.Rollout = &spinv1alpha1.RolloutStatus{
+		Phase:          spinv1alpha1.RolloutPhaseProgressing,
+		StableImage:    "app:v1",
+		CanaryImage:    "app:v2",
+		TrafficRouting: spinv1alpha1.RolloutTrafficRoutingHTTPRoute,
+	}
+	require.NoError(t, r.deleteCanary(ctx, app))
+	require.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(canaryDeployment), &appsv1.Deployment{}))
+	require.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(canaryService), &corev1.Service{}))
+
+	// Both are removed once the rollout completes
+	app.Status.Rollout = &spinv1alpha1.RolloutStatus{Phase: spinv1alpha1.RolloutPhaseStable, StableImage: "app:v2"}
+	require.NoError(t, r.deleteCanary(ctx, app))
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(canaryDeployment), &appsv1.Deployment{})
+	require.True(t, apierrors.IsNotFound(err))
+	err = r.Client.Get(ctx, client.ObjectKeyFromObject(canaryService), &corev1.Service{})
+	require.True(t, apierrors.IsNotFound(err))
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+)
+
+// revisionName returns the name of the SpinAppRevision with the given number
+// for a SpinApp.
+func revisionName(app *spinv1alpha1.SpinApp, revision int64) string {
+	return fmt.Sprintf("%s-%d", app.Name, revision)
+}
+
+// revisionSnapshot captures the effective configuration of a SpinApp.
+func revisionSnapshot(app *spinv1alpha1.SpinApp, runtimeConfigSecretName string) spinv1alpha1.SpinAppRevisionSpec {
+	spec := app.Spec.DeepCopy()
+	return spinv1alpha1.SpinAppRevisionSpec{
+		Image:                   spec.Image,
+		Executor:                spec.Executor,
+		RuntimeConfigSecretName: runtimeConfigSecretName,
+		RuntimeConfig:           spec.RuntimeConfig,
+		Variables:               spec.Variables,
+	}
+}
+
+// constructRevision builds a SpinAppRevision of a SpinApp.
+func constructRevision(app *spinv1alpha1.SpinApp, revision int64, spec spinv1alpha1.SpinAppRevisionSpec) *spinv1alpha1.SpinAppRevision {
+	return &spinv1alpha1.SpinAppRevision{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "SpinAppRevision",
+			APIVersion: spinv1alpha1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      revisionName(app, revision),
+			Namespace: app.Namespace,
+			Labels:    constructAppLabels(app),
+		},
+		Revision: revision,
+		Spec:     spec,
+	}
+}
+
+// applyRevision restores the configuration of a SpinAppRevision onto a
+// SpinApp.
+func applyRevision(app *spinv1alpha1.SpinApp, revision *spinv1alpha1.SpinAppRevision) {
+	spec := revision.Spec.DeepCopy()
+	app.Spec.Image = spec.Image
+	app.Spec.Executor = spec.Executor
+	app.Spec.RuntimeConfig = spec.RuntimeConfig
+	app.Spec.Variables = spec.Variables
+}
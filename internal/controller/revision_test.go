@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/generics"
+)
+
+func TestRevisionSnapshot(t *testing.T) {
+	t.Parallel()
+
+	app := minimalSpinApp()
+	app.Spec.Variables = []spinv1alpha1.SpinVar{{Name: "greeting", Value: "hello"}}
+
+	snapshot := revisionSnapshot(app, "my-app-rc-abc")
+	require.Equal(t, app.Spec.Image, snapshot.Image)
+	require.Equal(t, app.Spec.Executor, snapshot.Executor)
+	require.Equal(t, "my-app-rc-abc", snapshot.RuntimeConfigSecretName)
+	require.Equal(t, app.Spec.Variables, snapshot.Variables)
+
+	// The snapshot doesn't share state with the app
+	app.Spec.Variables[0].Value = "goodbye"
+	require.Equal(t, "hello", snapshot.Variables[0].Value)
+
+	// Applying a revision restores the captured configuration only
+	revision := constructRevision(app, 3, snapshot)
+	require.Equal(t, "my-app-3", revision.Name)
+	require.Equal(t, int64(3), revision.Revision)
+
+	app.Spec.Image = "fakereg.dev/noapp:v2"
+	app.Spec.Replicas = 5
+	applyRevision(app, revision)
+	require.Equal(t, "fakereg.dev/noapp:latest", app.Spec.Image)
+	require.Equal(t, "hello", app.Spec.Variables[0].Value)
+	require.Equal(t, int32(5), app.Spec.Replicas)
+}
+
+func TestReconcileRevisions(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	app := minimalSpinApp()
+	app.UID = "my-app-uid"
+
+	r := &SpinAppReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(app).Build(),
+		Scheme: scheme,
+
+		SpinAppRevisionHistoryLimit: 2,
+	}
+
+	ctx := context.Background()
+
+	// The first reconcile records revision 1
+	current, err := r.reconcileRevisions(ctx, app, "")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), current)
+
+	// Nothing changes when the configuration is unchanged
+	current, err = r.reconcileRevisions(ctx, app, "")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), current)
+	revisions, err := r.listRevisions(ctx, app)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+
+	// Changing the image records a new revision and prunes beyond the limit
+	for _, image := range []string{"fakereg.dev/noapp:v2", "fakereg.dev/noapp:v3"} {
+		app.Spec.Image = image
+		current, err = r.reconcileRevisions(ctx, app, "")
+		require.NoError(t, err)
+	}
+	require.Equal(t, int64(3), current)
+
+	revisions, err = r.listRevisions(ctx, app)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	require.Equal(t, int64(3), revisions[0].Revision)
+	require.Equal(t, "fakereg.dev/noapp:v3", revisions[0].Spec.Image)
+	require.Equal(t, int64(2), revisions[1].Revision)
+
+	// Revisions of other apps are ignored
+	other := minimalSpinApp()
+	other.Name = "other-app"
+	other.UID = "other-app-uid"
+	revisions, err = r.listRevisions(ctx, other)
+	require.NoError(t, err)
+	require.Empty(t, revisions)
+}
+
+func TestRollback(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	app := minimalSpinApp()
+	app.UID = "my-app-uid"
+
+	recorder := record.NewFakeRecorder(2)
+	r := &SpinAppReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(app).Build(),
+		Scheme:   scheme,
+		Recorder: recorder,
+
+		SpinAppRevisionHistoryLimit: 10,
+	}
+
+	ctx := context.Background()
+	_, err := r.reconcileRevisions(ctx, app, "")
+	require.NoError(t, err)
+
+	app.Spec.Image = "fakereg.dev/noapp:v2"
+	_, err = r.reconcileRevisions(ctx, app, "")
+	require.NoError(t, err)
+
+	// Rolling back restores revision 1 and clears rollbackTo
+	app.Spec.RollbackTo = generics.Ptr(int64(1))
+	require.NoError(t, r.rollback(ctx, app))
+	require.Contains(t, <-recorder.Events, "RolledBack")
+
+	var updated spinv1alpha1.SpinApp
+	require.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(app), &updated))
+	require.Nil(t, updated.Spec.RollbackTo)
+	require.Equal(t, "fakereg.dev/noapp:latest", updated.Spec.Image)
+
+	// The rolled back configuration matches revision 1 but is recorded as a
+	// new revision
+	current, err := r.reconcileRevisions(ctx, &updated, "")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), current)
+
+	// Unknown revisions are reported and leave the app unchanged
+	updated.Spec.RollbackTo = generics.Ptr(int64(42))
+	require.NoError(t, r.rollback(ctx, &updated))
+	require.Contains(t, <-recorder.Events, "RollbackRevisionNotFound")
+	require.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(app), &updated))
+	require.Nil(t, updated.Spec.RollbackTo)
+	require.Equal(t, "fakereg.dev/noapp:latest", updated.Spec.Image)
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+)
+
+const (
+	// spinAppConditionRolloutFailed reports whether the latest rollout of a
+	// SpinApp exceeded its progress deadline.
+	spinAppConditionRolloutFailed = "RolloutFailed"
+
+	// progressDeadlineExceededReason is the reason the Deployment controller
+	// sets on the Progressing condition of a Deployment whose rollout exceeded
+	// its progress deadline.
+	progressDeadlineExceededReason = "ProgressDeadlineExceeded"
+)
+
+// mergeRolloutControls merges the rollout controls of a SpinApp onto the
+// defaults of its executor. Fields set on the app take precedence.
+func mergeRolloutControls(app *spinv1alpha1.SpinApp, config *spinv1alpha1.ExecutorDeploymentConfig) spinv1alpha1.RolloutControls {
+	merged := *config.RolloutControls.DeepCopy()
+	overrides := app.Spec.RolloutControls.DeepCopy()
+
+	if overrides.Strategy != nil {
+		merged.Strategy = overrides.Strategy
+	}
+	if overrides.MinReadySeconds != nil {
+		merged.MinReadySeconds = overrides.MinReadySeconds
+	}
+	if overrides.ProgressDeadlineSeconds != nil {
+		merged.ProgressDeadlineSeconds = overrides.ProgressDeadlineSeconds
+	}
+	if overrides.RevisionHistoryLimit != nil {
+		merged.RevisionHistoryLimit = overrides.RevisionHistoryLimit
+	}
+
+	return merged
+}
+
+// rolloutFailedCondition derives the RolloutFailed condition of a SpinApp from
+// the Progressing condition of its Deployment.
+func rolloutFailedCondition(deployment *appsv1.Deployment) metav1.Condition {
+	for _, dc := range deployment.Status.Conditions {
+		if dc.Type == appsv1.DeploymentProgressing && dc.Reason == progressDeadlineExceededReason {
+			return metav1.Condition{
+				Type:    spinAppConditionRolloutFailed,
+				Status:  metav1.ConditionTrue,
+				Reason:  progressDeadlineExceededReason,
+				Message: dc.Message,
+			}
+		}
+	}
+
+	return metav1.Condition{
+		Type:    spinAppConditionRolloutFailed,
+		Status:  metav1.ConditionFalse,
+		Reason:  "RolloutWithinDeadline",
+		Message: "Deployment has not exceeded its progress deadline",
+	}
+}
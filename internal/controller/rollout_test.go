@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/generics"
+)
+
+func TestMergeRolloutControls(t *testing.T) {
+	t.Parallel()
+
+	config := &spinv1alpha1.ExecutorDeploymentConfig{
+		RuntimeClassName: generics.Ptr("wasmtime-spin-v2"),
+		RolloutControls: spinv1alpha1.RolloutControls{
+			Strategy:                &appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			ProgressDeadlineSeconds: generics.Ptr(int32(900)),
+			RevisionHistoryLimit:    generics.Ptr(int32(3)),
+		},
+	}
+
+	app := minimalSpinApp()
+	app.Spec.MinReadySeconds = generics.Ptr(int32(10))
+	app.Spec.RevisionHistoryLimit = generics.Ptr(int32(0))
+
+	merged := mergeRolloutControls(app, config)
+	require.Equal(t, config.Strategy, merged.Strategy)
+	require.Equal(t, generics.Ptr(int32(10)), merged.MinReadySeconds)
+	require.Equal(t, generics.Ptr(int32(900)), merged.ProgressDeadlineSeconds)
+	require.Equal(t, generics.Ptr(int32(0)), merged.RevisionHistoryLimit)
+
+	deployment, err := constructDeployment(context.Background(), app, config, "", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, appsv1.RecreateDeploymentStrategyType, deployment.Spec.Strategy.Type)
+	require.Equal(t, int32(10), deployment.Spec.MinReadySeconds)
+	require.Equal(t, generics.Ptr(int32(900)), deployment.Spec.ProgressDeadlineSeconds)
+	require.Equal(t, generics.Ptr(int32(0)), deployment.Spec.RevisionHistoryLimit)
+
+	// The Deployment API defaults are used when nothing is configured
+	deployment, err = constructDeployment(context.Background(), minimalSpinApp(),
+		&spinv1alpha1.ExecutorDeploymentConfig{RuntimeClassName: generics.Ptr("wasmtime-spin-v2")}, "", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, appsv1.DeploymentStrategy{}, deployment.Spec.Strategy)
+	require.Zero(t, deployment.Spec.MinReadySeconds)
+	require.Nil(t, deployment.Spec.ProgressDeadlineSeconds)
+	require.Nil(t, deployment.Spec.RevisionHistoryLimit)
+}
+
+func TestRolloutFailedCondition(t *testing.T) {
+	t.Parallel()
+
+	deployment := &appsv1.Deployment{
+		Status: appsv1.DeploymentStatus{
+			Conditions: []appsv1.DeploymentCondition{{
+				Type:    appsv1.DeploymentProgressing,
+				Status:  corev1.ConditionTrue,
+				Reason:  "NewReplicaSetAvailable",
+				Message: `ReplicaSet "my-app-abc" has successfully progressed.`,
+			}},
+		},
+	}
+
+	condition := rolloutFailedCondition(deployment)
+	require.Equal(t, spinAppConditionRolloutFailed, condition.Type)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+
+	deployment.Status.Conditions[0] = appsv1.DeploymentCondition{
+		Type:    appsv1.DeploymentProgressing,
+		Status:  corev1.ConditionFalse,
+		Reason:  "ProgressDeadlineExceeded",
+		Message: `ReplicaSet "my-app-def" has timed out progressing.`,
+	}
+	condition = rolloutFailedCondition(deployment)
+	require.Equal(t, metav1.ConditionTrue, condition.Status)
+	require.Equal(t, "ProgressDeadlineExceeded", condition.Reason)
+	require.Equal(t, `ReplicaSet "my-app-def" has timed out progressing.`, condition.Message)
+}
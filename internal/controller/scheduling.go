@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+)
+
+// mergePodScheduling merges the scheduling configuration of a SpinApp onto the
+// scheduling defaults of its executor, as documented on
+// spinv1alpha1.PodScheduling.
+func mergePodScheduling(app *spinv1alpha1.SpinApp, config *spinv1alpha1.ExecutorDeploymentConfig) spinv1alpha1.PodScheduling {
+	defaults := config.PodScheduling
+	overrides := app.Spec.PodScheduling
+
+	var merged spinv1alpha1.PodScheduling
+
+	if len(defaults.NodeSelector) > 0 || len(overrides.NodeSelector) > 0 {
+		merged.NodeSelector = maps.Clone(defaults.NodeSelector)
+		if merged.NodeSelector == nil {
+			merged.NodeSelector = map[string]string{}
+		}
+		maps.Copy(merged.NodeSelector, overrides.NodeSelector)
+	}
+
+	merged.Tolerations = slices.Clone(defaults.Tolerations)
+	for _, toleration := range overrides.Tolerations {
+		if !slices.ContainsFunc(merged.Tolerations, func(t corev1.Toleration) bool {
+			return equality.Semantic.DeepEqual(t, toleration)
+		}) {
+			merged.Tolerations = append(merged.Tolerations, toleration)
+		}
+	}
+
+	merged.Affinity = mergeAffinity(defaults.Affinity, overrides.Affinity)
+
+	merged.TopologySpreadConstraints = slices.Clone(defaults.TopologySpreadConstraints)
+	for _, constraint := range overrides.TopologySpreadConstraints {
+		i := slices.IndexFunc(merged.TopologySpreadConstraints, func(c corev1.TopologySpreadConstraint) bool {
+			return c.TopologyKey == constraint.TopologyKey && c.WhenUnsatisfiable == constraint.WhenUnsatisfiable
+		})
+		if i >= 0 {
+			merged.TopologySpreadConstraints[i] = constraint
+		} else {
+			merged.TopologySpreadConstraints = append(merged.TopologySpreadConstraints, constraint)
+		}
+	}
+	// Executors can't know the labels of the apps using them, so constraints
+	// without a selector spread the pods of the app.
+	for i, constraint := range merged.TopologySpreadConstraints {
+		if constraint.LabelSelector == nil {
+			constraint.LabelSelector = &metav1.LabelSelector{MatchLabels: constructPodSelectorLabels(app)}
+			merged.TopologySpreadConstraints[i] = constraint
+		}
+	}
+
+	merged.PriorityClassName = defaults.PriorityClassName
+	if overrides.PriorityClassName != "" {
+		merged.PriorityClassName = overrides.PriorityClassName
+	}
+
+	return merged
+}
+
+// mergeAffinity merges the affinity of an app onto the affinity of its
+// executor. Each kind of affinity of the app replaces that of the executor.
+func mergeAffinity(defaults, overrides *corev1.Affinity) *corev1.Affinity {
+	if defaults == nil && overrides == nil {
+		return nil
+	}
+
+	merged := &corev1.Affinity{}
+	if defaults != nil {
+		merged = defaults.DeepCopy()
+	}
+	if overrides != nil {
+		if overrides.NodeAffinity != nil {
+			merged.NodeAffinity = overrides.NodeAffinity.DeepCopy()
+		}
+		if overrides.PodAffinity != nil {
+			merged.PodAffinity = overrides.PodAffinity.DeepCopy()
+		}
+		if overrides.PodAntiAffinity != nil {
+			merged.PodAntiAffinity = overrides.PodAntiAffinity.DeepCopy()
+		}
+	}
+	return merged
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/generics"
+)
+
+func TestMergePodScheduling(t *testing.T) {
+	t.Parallel()
+
+	shimToleration := corev1.Toleration{
+		Key:      "spinkube.dev/shim",
+		Operator: corev1.TolerationOpExists,
+		Effect:   corev1.TaintEffectNoSchedule,
+	}
+	gpuToleration := corev1.Toleration{
+		Key:      "nvidia.com/gpu",
+		Operator: corev1.TolerationOpExists,
+		Effect:   corev1.TaintEffectNoSchedule,
+	}
+	nodeAffinity := func(zone string) *corev1.NodeAffinity {
+		return &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{{
+						Key:      "topology.kubernetes.io/zone",
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   []string{zone},
+					}},
+				}},
+			},
+		}
+	}
+	antiAffinity := &corev1.PodAntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+			Weight: 100,
+			PodAffinityTerm: corev1.PodAffinityTerm{
+				TopologyKey:   "kubernetes.io/hostname",
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			},
+		}},
+	}
+
+	config := &spinv1alpha1.ExecutorDeploymentConfig{
+		PodScheduling: spinv1alpha1.PodScheduling{
+			NodeSelector: map[string]string{"spinkube.dev/shim": "true", "kubernetes.io/arch": "amd64"},
+			Tolerations:  []corev1.Toleration{shimToleration},
+			Affinity: &corev1.Affinity{
+				NodeAffinity:    nodeAffinity("us-east-1a"),
+				PodAntiAffinity: antiAffinity,
+			},
+			TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+				{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.ScheduleAnyway},
+				{MaxSkew: 1, TopologyKey: "kubernetes.io/hostname", WhenUnsatisfiable: corev1.ScheduleAnyway},
+			},
+			PriorityClassName: "spin-apps",
+		},
+	}
+
+	// Apps without scheduling configuration use the executor defaults
+	app := minimalSpinApp()
+	merged := mergePodScheduling(app, config)
+	require.Equal(t, config.NodeSelector, merged.NodeSelector)
+	require.Equal(t, []corev1.Toleration{shimToleration}, merged.Tolerations)
+	require.Equal(t, config.Affinity, merged.Affinity)
+	require.Equal(t, "spin-apps", merged.PriorityClassName)
+	require.Len(t, merged.TopologySpreadConstraints, 2)
+	// Constraints without a selector spread the pods of the app
+	for _, constraint := range merged.TopologySpreadConstraints {
+		require.Equal(t, constructPodSelectorLabels(app), constraint.LabelSelector.MatchLabels)
+	}
+	require.Nil(t, config.TopologySpreadConstraints[0].LabelSelector)
+
+	app.Spec.PodScheduling = spinv1alpha1.PodScheduling{
+		NodeSelector: map[string]string{"kubernetes.io/arch": "arm64"},
+		Tolerations:  []corev1.Toleration{shimToleration, gpuToleration},
+		Affinity:     &corev1.Affinity{NodeAffinity: nodeAffinity("us-east-1b")},
+		TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+			{MaxSkew: 2, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.ScheduleAnyway},
+			{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule},
+		},
+		PriorityClassName: "critical",
+	}
+	merged = mergePodScheduling(app, config)
+	require.Equal(t, map[string]string{"spinkube.dev/shim": "true", "kubernetes.io/arch": "arm64"}, merged.NodeSelector)
+	require.Equal(t, []corev1.Toleration{shimToleration, gpuToleration}, merged.Tolerations)
+	require.Equal(t, nodeAffinity("us-east-1b"), merged.Affinity.NodeAffinity)
+	require.Equal(t, antiAffinity, merged.Affinity.PodAntiAffinity)
+	require.Equal(t, "critical", merged.PriorityClassName)
+	require.Len(t, merged.TopologySpreadConstraints, 3)
+	require.Equal(t, int32(2), merged.TopologySpreadConstraints[0].MaxSkew)
+	require.Equal(t, "kubernetes.io/hostname", merged.TopologySpreadConstraints[1].TopologyKey)
+	require.Equal(t, corev1.DoNotSchedule, merged.TopologySpreadConstraints[2].WhenUnsatisfiable)
+
+	// The executor defaults aren't modified
+	require.Equal(t, "amd64", config.NodeSelector["kubernetes.io/arch"])
+	require.Equal(t, nodeAffinity("us-east-1a"), config.Affinity.NodeAffinity)
+	require.Len(t, config.Tolerations, 1)
+}
+
+func TestConstructDeployment_PodScheduling(t *testing.T) {
+	t.Parallel()
+
+	cfg := &spinv1alpha1.ExecutorDeploymentConfig{
+		RuntimeClassName: generics.Ptr("bananarama"),
+		PodScheduling: spinv1alpha1.PodScheduling{
+			Tolerations: []corev1.Toleration{{Key: "spinkube.dev/shim", Operator: corev1.TolerationOpExists}},
+		},
+	}
+
+	app := minimalSpinApp()
+	app.Spec.NodeSelector = map[string]string{"spinkube.dev/shim": "true"}
+	app.Spec.PriorityClassName = "critical"
+
+	deployment, err := constructDeployment(context.Background(), app, cfg, "", "", nil)
+	require.NoError(t, err)
+	podSpec := deployment.Spec.Template.Spec
+	require.Equal(t, map[string]string{"spinkube.dev/shim": "true"}, podSpec.NodeSelector)
+	require.Equal(t, cfg.Tolerations, podSpec.Tolerations)
+	require.Equal(t, "critical", podSpec.PriorityClassName)
+	require.Nil(t, podSpec.Affinity)
+	require.Empty(t, podSpec.TopologySpreadConstraints)
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+)
+
+// mergePodSecurityContext merges the pod security context of a SpinApp onto
+// the defaults of its executor. Every field that is set by the app replaces
+// that of the executor.
+//
+// There are no built-in defaults: Wasm images run by a shim have no user to
+// verify `runAsNonRoot` against, and the Spin image of the spintainer executor
+// runs as root. Pods only get a security context when the executor or the app
+// sets one.
+func mergePodSecurityContext(app *spinv1alpha1.SpinApp, config *spinv1alpha1.ExecutorDeploymentConfig) (*corev1.PodSecurityContext, error) {
+	return overlayFields(config.PodSecurityContext, app.Spec.PodSecurityContext)
+}
+
+// mergeSecurityContext merges the container security context of a SpinApp
+// onto the defaults of its executor. Every field that is set by the app
+// replaces that of the executor.
+func mergeSecurityContext(app *spinv1alpha1.SpinApp, config *spinv1alpha1.ExecutorDeploymentConfig) (*corev1.SecurityContext, error) {
+	return overlayFields(config.SecurityContext, app.Spec.SecurityContext)
+}
+
+// overlayFields returns a copy of defaults where every top level field that is
+// set in overrides is replaced. Fields are compared by their JSON
+// representation, so omitted fields are considered unset.
+func overlayFields[T any](defaults, overrides *T) (*T, error) {
+	if defaults == nil || overrides == nil {
+		if overrides != nil {
+			return overrides, nil
+		}
+		return defaults, nil
+	}
+
+	fields := map[string]json.RawMessage{}
+	for _, obj := range []*T{defaults, overrides} {
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %T: %w", obj, err)
+		}
+		var objFields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &objFields); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %T: %w", obj, err)
+		}
+		maps.Copy(fields, objFields)
+	}
+
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal merged %T: %w", defaults, err)
+	}
+	merged := new(T)
+	if err := json.Unmarshal(raw, merged); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal merged %T: %w", defaults, err)
+	}
+	return merged, nil
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/generics"
+)
+
+func TestConstructDeployment_NoDefaultSecurityContext(t *testing.T) {
+	t.Parallel()
+
+	// Neither shim nor spintainer executors get a security context unless
+	// they opt in
+	for _, cfg := range []*spinv1alpha1.ExecutorDeploymentConfig{
+		{RuntimeClassName: generics.Ptr("bananarama")},
+		{SpinImage: generics.Ptr("ghcr.io/fermyon/spin:v2.7.0")},
+	} {
+		deployment, err := constructDeployment(context.Background(), minimalSpinApp(), cfg, "", "", nil)
+		require.NoError(t, err)
+		podSpec := deployment.Spec.Template.Spec
+		require.Nil(t, podSpec.SecurityContext)
+		require.Nil(t, podSpec.Containers[0].SecurityContext)
+	}
+}
+
+func TestConstructDeployment_SecurityContext(t *testing.T) {
+	t.Parallel()
+
+	cfg := &spinv1alpha1.ExecutorDeploymentConfig{
+		SpinImage: generics.Ptr("ghcr.io/fermyon/spin:v2.7.0"),
+		PodSecurityContext: &corev1.PodSecurityContext{
+			RunAsNonRoot:   generics.Ptr(true),
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			AllowPrivilegeEscalation: generics.Ptr(false),
+			Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		},
+	}
+
+	// Apps without security configuration get the executor defaults
+	app := minimalSpinApp()
+	deployment, err := constructDeployment(context.Background(), app, cfg, "", "", nil)
+	require.NoError(t, err)
+	podSpec := deployment.Spec.Template.Spec
+	require.Equal(t, cfg.PodSecurityContext, podSpec.SecurityContext)
+	require.Equal(t, cfg.SecurityContext, podSpec.Containers[0].SecurityContext)
+	require.Empty(t, podSpec.ServiceAccountName)
+	require.Nil(t, podSpec.AutomountServiceAccountToken)
+
+	// Fields set by the app replace those of the executor
+	app.Spec.ServiceAccountName = "my-app"
+	app.Spec.AutomountServiceAccountToken = generics.Ptr(false)
+	app.Spec.PodSecurityContext = &corev1.PodSecurityContext{RunAsUser: generics.Ptr(int64(1000))}
+	app.Spec.SecurityContext = &corev1.SecurityContext{
+		ReadOnlyRootFilesystem: generics.Ptr(true),
+		Capabilities:           &corev1.Capabilities{Drop: []corev1.Capability{"NET_RAW"}},
+	}
+	deployment, err = constructDeployment(context.Background(), app, cfg, "", "", nil)
+	require.NoError(t, err)
+	podSpec = deployment.Spec.Template.Spec
+	require.Equal(t, "my-app", podSpec.ServiceAccountName)
+	require.Equal(t, generics.Ptr(false), podSpec.AutomountServiceAccountToken)
+	require.Equal(t, &corev1.PodSecurityContext{
+		RunAsNonRoot:   generics.Ptr(true),
+		RunAsUser:      generics.Ptr(int64(1000)),
+		SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}, podSpec.SecurityContext)
+	require.Equal(t, &corev1.SecurityContext{
+		AllowPrivilegeEscalation: generics.Ptr(false),
+		ReadOnlyRootFilesystem:   generics.Ptr(true),
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"NET_RAW"}},
+	}, podSpec.Containers[0].SecurityContext)
+
+	// The executor defaults aren't modified
+	require.Nil(t, cfg.PodSecurityContext.RunAsUser)
+
+	// Without executor defaults the app security context is used as is
+	deployment, err = constructDeployment(context.Background(), app,
+		&spinv1alpha1.ExecutorDeploymentConfig{RuntimeClassName: generics.Ptr("bananarama")}, "", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, app.Spec.SecurityContext, deployment.Spec.Template.Spec.Containers[0].SecurityContext)
+}
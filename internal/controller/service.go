@@ -19,6 +19,31 @@ func constructService(app *spinv1alpha1.SpinApp) *corev1.Service {
 
 	statusKey, statusValue := spinapp.ConstructStatusReadyLabel(app.Name)
 	selector := map[string]string{statusKey: statusValue}
+	if rolloutInProgress(app) {
+		selector = rolloutTrackSelector(app, serviceRolloutTrack(app))
+	}
+
+	config := app.Spec.Service
+	if config == nil {
+		config = &spinv1alpha1.ServiceConfig{}
+	}
+
+	serviceType := config.Type
+	if serviceType == "" {
+		serviceType = corev1.ServiceTypeClusterIP
+	}
+
+	port := corev1.ServicePort{
+		Protocol: corev1.ProtocolTCP,
+		TargetPort: intstr.IntOrString{
+			Type:   intstr.String,
+			StrVal: spinapp.HTTPPortName,
+		},
+		Port: servicePort(app),
+	}
+	if config.NodePort != nil {
+		port.NodePort = *config.NodePort
+	}
 
 	svc := &corev1.Service{
 		TypeMeta: metav1.TypeMeta{
@@ -32,24 +57,37 @@ func constructService(app *spinv1alpha1.SpinApp) *corev1.Service {
 			Annotations: annotations,
 		},
 		Spec: corev1.ServiceSpec{
-			Type: corev1.ServiceTypeClusterIP,
-			Ports: []corev1.ServicePort{
-				{
-					Protocol: corev1.ProtocolTCP,
-					TargetPort: intstr.IntOrString{
-						Type:   intstr.String,
-						StrVal: spinapp.HTTPPortName,
-					},
-					Port: spinapp.DefaultHTTPPort,
-				},
-			},
-			Selector: selector,
+			Type:                     serviceType,
+			Ports:                    []corev1.ServicePort{port},
+			Selector:                 selector,
+			ExternalTrafficPolicy:    config.ExternalTrafficPolicy,
+			SessionAffinity:          config.SessionAffinity,
+			LoadBalancerSourceRanges: config.LoadBalancerSourceRanges,
+			IPFamilies:               config.IPFamilies,
+			IPFamilyPolicy:           config.IPFamilyPolicy,
 		},
 	}
 
+	if config.Headless {
+		svc.Spec.ClusterIP = corev1.ClusterIPNone
+	}
+
 	return svc
 }
 
+// servicePort returns the port the Service of a SpinApp serves the app on.
+func servicePort(app *spinv1alpha1.SpinApp) int32 {
+	if app.Spec.Service != nil && app.Spec.Service.Port != nil {
+		return *app.Spec.Service.Port
+	}
+	return spinapp.DefaultHTTPPort
+}
+
+// skipService returns whether the Service of a SpinApp is disabled.
+func skipService(app *spinv1alpha1.SpinApp) bool {
+	return app.Spec.Service != nil && app.Spec.Service.Skip
+}
+
 // constructAppLabels returns the labels to add to deployment/service
 // objects for the given SpinApp
 func constructAppLabels(app *spinv1alpha1.SpinApp) map[string]string {
@@ -1,9 +1,20 @@
 package controller
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/generics"
 )
 
 func TestConstructService(t *testing.T) {
@@ -29,3 +40,66 @@ func TestConstructService(t *testing.T) {
 	require.Equal(t, int32(80), svc.Spec.Ports[0].Port)
 	require.Equal(t, "http-app", svc.Spec.Ports[0].TargetPort.StrVal)
 }
+
+func TestConstructService_Config(t *testing.T) {
+	t.Parallel()
+
+	app := minimalSpinApp()
+	app.Spec.Service = &spinv1alpha1.ServiceConfig{
+		Type:                     corev1.ServiceTypeLoadBalancer,
+		Port:                     generics.Ptr(int32(8080)),
+		NodePort:                 generics.Ptr(int32(30080)),
+		ExternalTrafficPolicy:    corev1.ServiceExternalTrafficPolicyLocal,
+		SessionAffinity:          corev1.ServiceAffinityClientIP,
+		LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+		IPFamilies:               []corev1.IPFamily{corev1.IPv6Protocol},
+		IPFamilyPolicy:           generics.Ptr(corev1.IPFamilyPolicySingleStack),
+	}
+
+	svc := constructService(app)
+	require.Equal(t, corev1.ServiceTypeLoadBalancer, svc.Spec.Type)
+	require.Empty(t, svc.Spec.ClusterIP)
+	require.Len(t, svc.Spec.Ports, 1)
+	require.Equal(t, int32(8080), svc.Spec.Ports[0].Port)
+	require.Equal(t, int32(30080), svc.Spec.Ports[0].NodePort)
+	require.Equal(t, "http-app", svc.Spec.Ports[0].TargetPort.StrVal)
+	require.Equal(t, corev1.ServiceExternalTrafficPolicyLocal, svc.Spec.ExternalTrafficPolicy)
+	require.Equal(t, corev1.ServiceAffinityClientIP, svc.Spec.SessionAffinity)
+	require.Equal(t, []string{"10.0.0.0/8"}, svc.Spec.LoadBalancerSourceRanges)
+	require.Equal(t, []corev1.IPFamily{corev1.IPv6Protocol}, svc.Spec.IPFamilies)
+	require.Equal(t, generics.Ptr(corev1.IPFamilyPolicySingleStack), svc.Spec.IPFamilyPolicy)
+
+	app.Spec.Service = &spinv1alpha1.ServiceConfig{Headless: true}
+	svc = constructService(app)
+	require.Equal(t, corev1.ServiceTypeClusterIP, svc.Spec.Type)
+	require.Equal(t, corev1.ClusterIPNone, svc.Spec.ClusterIP)
+	require.Equal(t, int32(80), svc.Spec.Ports[0].Port)
+}
+
+func TestReconcileService_Skip(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	app := minimalSpinApp()
+	app.UID = "my-app-uid"
+	app.Spec.Service = &spinv1alpha1.ServiceConfig{Skip: true}
+
+	owned := constructService(app)
+	require.NoError(t, controllerutil.SetControllerReference(app, owned, scheme))
+
+	r := &SpinAppReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(owned).Build(),
+		Scheme: scheme,
+	}
+
+	ctx := context.Background()
+	require.NoError(t, r.reconcileService(ctx, app))
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(owned), &corev1.Service{})
+	require.True(t, apierrors.IsNotFound(err))
+
+	// Skipping again is a no-op
+	require.NoError(t, r.reconcileService(ctx, app))
+}
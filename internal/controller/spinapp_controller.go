@@ -17,25 +17,38 @@ limitations under the License.
 package controller
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"hash/adler32"
 	"maps"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/pelletier/go-toml/v2"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
 	"github.com/spinkube/spin-operator/internal/cacerts"
@@ -54,6 +67,10 @@ const (
 
 	// FieldManger is used to declare that the spin operator owns specific fields on child resources
 	FieldManager = "spin-operator"
+
+	// crdDiscoveryInterval is how often apps that use resources of optional
+	// CRDs (KEDA, the Gateway API) check whether the CRD has been installed.
+	crdDiscoveryInterval = 5 * time.Minute
 )
 
 // SpinAppReconciler reconciles a SpinApp object
@@ -61,27 +78,91 @@ type SpinAppReconciler struct {
 	Client   client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+
+	// RuntimeConfigHistoryLimit is the number of previous generated runtime
+	// config secrets to retain for an app, in addition to the current one, as
+	// long as they are still referenced by one of the app's ReplicaSets.
+	// Secrets mounted by running pods are always retained.
+	RuntimeConfigHistoryLimit int
+
+	// SpinAppRevisionHistoryLimit is the number of SpinAppRevisions to retain
+	// per app, including the current one.
+	SpinAppRevisionHistoryLimit int
+
+	// DefaultDisruptionBudget is the disruption budget of apps with more than
+	// one replica that don't configure their own. No PodDisruptionBudget is
+	// created for them when this is nil.
+	DefaultDisruptionBudget *spinv1alpha1.DisruptionBudget
 }
 
 //+kubebuilder:rbac:groups=core.spinkube.dev,resources=spinapps,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core.spinkube.dev,resources=spinapps/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=core.spinkube.dev,resources=spinapps/scale,verbs=get;update
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=apps,resources=deployments/status,verbs=get
+//+kubebuilder:rbac:groups=apps,resources=replicasets,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core.spinkube.dev,resources=spinapprevisions,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=keda.sh,resources=scaledobjects,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *SpinAppReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &spinv1alpha1.SpinApp{}, spinAppSecretRefsKey, func(rawObj client.Object) []string {
+		return dependenciesForApp(rawObj.(*spinv1alpha1.SpinApp)).Secrets
+	}); err != nil {
+		return err
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &spinv1alpha1.SpinApp{}, spinAppConfigMapRefsKey, func(rawObj client.Object) []string {
+		return dependenciesForApp(rawObj.(*spinv1alpha1.SpinApp)).ConfigMaps
+	}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&spinv1alpha1.SpinApp{}).
 		// Owns allows watching dependency resources for any changes
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.Secret{}).
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}).
+		Owns(&networkingv1.Ingress{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
+		// Executor changes (e.g a new spinImage or runtimeClassName) need to be
+		// rolled out to every app that uses them. We only care about spec changes
+		// so status and metadata updates are filtered out.
+		Watches(&spinv1alpha1.SpinAppExecutor{},
+			handler.EnqueueRequestsFromMapFunc(r.findSpinAppsForExecutor),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&spinv1alpha1.ClusterSpinAppExecutor{},
+			handler.EnqueueRequestsFromMapFunc(r.findSpinAppsForExecutor),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// Secrets and ConfigMaps referenced by runtime config or variables are
+		// re-read so that rotated values are rolled out to the app.
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.findSpinAppsForSecret)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.findSpinAppsForConfigMap)).
+		// Pods are owned by ReplicaSets, so failures of pods are mapped back to
+		// their app through its name label.
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.findSpinAppForPod)).
 		Complete(r)
 }
 
+// findSpinAppsForExecutor returns a reconcile request for every SpinApp that
+// references the given SpinAppExecutor or ClusterSpinAppExecutor. Cluster
+// executors have no namespace so apps in all namespaces are matched. It relies
+// on the spec.executor field index registered by the SpinAppExecutorReconciler.
+func (r *SpinAppReconciler) findSpinAppsForExecutor(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.findSpinAppsMatchingField(ctx, obj, spinAppExecutorKey)
+}
+
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 //
@@ -102,36 +183,91 @@ func (r *SpinAppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	var executor spinv1alpha1.SpinAppExecutor
-	if err := r.Client.Get(ctx, types.NamespacedName{
-		// Executors must currently be defined in the same namespace as the app.
-		// When we decide if the operator will be global or namespaced we may want
-		// executors to be global as they're a platform concern.
-		Namespace: req.NamespacedName.Namespace,
-		Name:      spinApp.Spec.Executor,
-	}, &executor); err != nil {
-		log.Error(err, "unable to fetch executor")
-		r.Recorder.Event(&spinApp, "Warning", "MissingExecutor",
-			fmt.Sprintf("Could not find SpinAppExecutor %s/%s", req.NamespacedName.Namespace, spinApp.Spec.Executor))
-		return ctrl.Result{}, err
+	// Spin app has been requested for deletion, child resources will
+	// automatically be deleted.
+	if !spinApp.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
 	}
 
-	// Update the status of the SpinApp
-	err := r.updateStatus(ctx, &spinApp, &executor)
+	// Rolling back changes the spec, which triggers another reconcile
+	if spinApp.Spec.RollbackTo != nil {
+		return ctrl.Result{}, r.rollback(ctx, &spinApp)
+	}
+
+	// The status is computed from what was observed while reconciling the
+	// child resources. It is applied even when that fails, so that the failure
+	// is reported. Failures are returned rather than requeued explicitly so
+	// that retries back off exponentially, e.g while a referenced secret or
+	// executor doesn't exist. Creating those triggers a reconcile anyway.
+	previous := spinApp.Status.DeepCopy()
+	var observed statusObservation
+	result, err := r.reconcileChildResources(ctx, &spinApp, &observed)
+	if err != nil && observed.FailureReason == "" {
+		observed.FailureReason = "ReconcileFailed"
+		observed.FailureMessage = err.Error()
+	}
+
+	if statusErr := r.applyStatus(ctx, &spinApp, previous, &observed); statusErr != nil {
+		log.Error(statusErr, "Unable to apply status")
+		err = errors.Join(err, statusErr)
+	}
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	// Spin app has been requested for deletion, child resources will
-	// automatically be deleted.
-	if !spinApp.DeletionTimestamp.IsZero() {
-		return ctrl.Result{}, nil
+	return result, nil
+}
+
+// reconcileChildResources reconciles the child resources of a SpinApp and
+// records what it observed along the way for the status of the app.
+func (r *SpinAppReconciler) reconcileChildResources(ctx context.Context, spinApp *spinv1alpha1.SpinApp, observed *statusObservation) (ctrl.Result, error) {
+	log := logging.FromContext(ctx)
+
+	executor, err := r.resolveExecutor(ctx, spinApp)
+	if apierrors.IsNotFound(err) {
+		log.Error(err, "unable to fetch executor")
+		message := fmt.Sprintf("Could not find SpinAppExecutor %s/%s or ClusterSpinAppExecutor %s",
+			spinApp.Namespace, spinApp.Spec.Executor, spinApp.Spec.Executor)
+		r.Recorder.Event(spinApp, "Warning", "MissingExecutor", message)
+		observed.FailureReason = "ExecutorNotFound"
+		observed.FailureMessage = message
+		return ctrl.Result{}, err
+	}
+	if err != nil {
+		log.Error(err, "unable to fetch executor")
+		observed.FailureReason = "ExecutorLookupFailed"
+		observed.FailureMessage = err.Error()
+		return ctrl.Result{}, err
 	}
+	observed.Executor = executor
 
 	// Reconcile the child resources
 
+	// Managed autoscalers set the replica count of the app, which needs to be
+	// initialized before the deployment is constructed from it.
+	if usesManagedAutoscaling(spinApp, executor) {
+		if err := r.initializeAutoscaledReplicas(ctx, spinApp); err != nil {
+			log.Error(err, "Unable to initialize replicas")
+			return ctrl.Result{}, err
+		}
+	}
+
 	if executor.Spec.CreateDeployment {
-		err := r.reconcileDeployment(ctx, &spinApp, executor.Spec.DeploymentConfig)
+		// Progressive rollouts advance based on the Deployments as they were
+		// before this reconcile, and the child resources are constructed from
+		// the advanced rollout.
+		if err := r.observeRollout(ctx, spinApp); err != nil {
+			log.Error(err, "Unable to observe rollout")
+			return ctrl.Result{}, err
+		}
+
+		observed.RuntimeConfigSecretName, err = r.reconcileDeployment(ctx, spinApp, executor.Spec.DeploymentConfig)
+		if errors.Is(err, errRuntimeConfigNotRendered) {
+			observed.RuntimeConfigError = err
+			observed.FailureReason, observed.FailureMessage = runtimeConfigFailure(err)
+		} else {
+			observed.RuntimeConfigRendered = true
+		}
 		if err != nil {
 			log.Error(err, "Failed to Reconcile Deployment")
 			return ctrl.Result{}, err
@@ -139,96 +275,252 @@ func (r *SpinAppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	} else {
 		// If we shouldn't be managing a deployment for an application ensure any
 		// previously created deployments have been cleaned up.
-		err := r.deleteDeployment(ctx, &spinApp)
+		err := r.deleteDeployment(ctx, spinApp)
 		if client.IgnoreNotFound(err) != nil {
 			return ctrl.Result{}, err
 		}
 	}
 
-	err = r.reconcileService(ctx, &spinApp)
+	observed.CurrentRevision, err = r.reconcileRevisions(ctx, spinApp, observed.RuntimeConfigSecretName)
+	if err != nil {
+		log.Error(err, "Failed to Reconcile SpinAppRevisions")
+		return ctrl.Result{}, err
+	}
+
+	err = r.reconcileService(ctx, spinApp)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err = r.reconcilePodDisruptionBudget(ctx, spinApp, executor)
+	if err != nil {
+		log.Error(err, "Failed to Reconcile PodDisruptionBudget")
+		return ctrl.Result{}, err
+	}
+
+	err = r.reconcileIngress(ctx, spinApp)
+	if err != nil {
+		log.Error(err, "Failed to Reconcile Ingress")
+		return ctrl.Result{}, err
+	}
+
+	// Resources of optional CRDs can't be watched when the CRD isn't
+	// installed, so check back later in case it has been installed in the
+	// meantime.
+	var result ctrl.Result
+
+	err = r.reconcileHTTPRoute(ctx, spinApp)
+	if errors.Is(err, errGatewayAPINotInstalled) {
+		observed.GatewayAPIInstalled = generics.Ptr(false)
+		result.RequeueAfter = crdDiscoveryInterval
+	} else if err != nil {
+		log.Error(err, "Failed to Reconcile HTTPRoute")
+		return ctrl.Result{}, err
+	} else if exposedThroughHTTPRoute(spinApp) {
+		observed.GatewayAPIInstalled = generics.Ptr(true)
+	}
+
+	// The canary is only removed once the Service and HTTPRoute no longer
+	// send traffic to it.
+	err = r.deleteCanary(ctx, spinApp)
+	if err != nil {
+		log.Error(err, "Failed to clean up canary")
+		return ctrl.Result{}, err
+	}
+
+	err = r.reconcileHorizontalPodAutoscaler(ctx, spinApp, executor)
 	if err != nil {
+		log.Error(err, "Failed to Reconcile HorizontalPodAutoscaler")
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	err = r.reconcileScaledObject(ctx, spinApp, executor)
+	if errors.Is(err, errKEDANotInstalled) {
+		observed.KEDAInstalled = generics.Ptr(false)
+		result.RequeueAfter = crdDiscoveryInterval
+	} else if err != nil {
+		log.Error(err, "Failed to Reconcile ScaledObject")
+		return ctrl.Result{}, err
+	} else if usesKEDAAutoscaling(spinApp, executor) {
+		observed.KEDAInstalled = generics.Ptr(true)
+	}
+
+	// Pauses of rollout steps end without any change to watched resources
+	if requeueAfter := rolloutRequeueAfter(spinApp, time.Now()); requeueAfter > 0 &&
+		(result.RequeueAfter == 0 || requeueAfter < result.RequeueAfter) {
+		result.RequeueAfter = requeueAfter
+	}
+
+	return result, nil
 }
 
-// updateStatus updates the status of a SpinApp.
-func (r *SpinAppReconciler) updateStatus(ctx context.Context, app *spinv1alpha1.SpinApp, executor *spinv1alpha1.SpinAppExecutor) error {
-	log := logging.FromContext(ctx)
+// resolveExecutor finds the executor for a SpinApp. A SpinAppExecutor in the
+// namespace of the app takes precedence, falling back to a
+// ClusterSpinAppExecutor with the same name.
+//
+// Cluster executors are returned as a SpinAppExecutor with the same name and
+// spec so that callers don't need to care about where the executor came from.
+func (r *SpinAppReconciler) resolveExecutor(ctx context.Context, app *spinv1alpha1.SpinApp) (*spinv1alpha1.SpinAppExecutor, error) {
+	var executor spinv1alpha1.SpinAppExecutor
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: app.Namespace, Name: app.Spec.Executor}, &executor)
+	if !apierrors.IsNotFound(err) {
+		if err != nil {
+			return nil, err
+		}
+		return &executor, nil
+	}
 
-	// Our only status management is currently based on the resulting deployment
-	// because of this, lets skip status interactions when the deployment is disabled.
-	if !executor.Spec.CreateDeployment {
-		return nil
+	var clusterExecutor spinv1alpha1.ClusterSpinAppExecutor
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: app.Spec.Executor}, &clusterExecutor); err != nil {
+		return nil, err
 	}
 
-	// Set the active scheduler
-	app.Status.ActiveScheduler = app.Spec.Executor
+	return &spinv1alpha1.SpinAppExecutor{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterExecutor.Name},
+		Spec:       clusterExecutor.Spec,
+	}, nil
+}
 
-	deployment, err := r.findDeploymentForApp(ctx, app)
+// observeRollout advances the progressive rollout of a SpinApp based on its
+// stable and canary Deployments. Transitions of the rollout are recorded as
+// events.
+func (r *SpinAppReconciler) observeRollout(ctx context.Context, app *spinv1alpha1.SpinApp) error {
+	stable, err := r.findDeploymentForApp(ctx, app)
 	if client.IgnoreNotFound(err) != nil {
-		log.Error(err, "Unable to find deployment for app")
 		return err
 	}
+	if err != nil {
+		stable = nil
+	}
 
-	if apierrors.IsNotFound(err) {
-		// Deployment doesn't exist yet so set conditions as unknown
-		meta.SetStatusCondition(
-			&app.Status.Conditions,
-			metav1.Condition{
-				Type:    "Available",
-				Status:  metav1.ConditionUnknown,
-				Reason:  "DeploymentNotFound",
-				Message: "Deployment not found",
-			})
-		meta.SetStatusCondition(
-			&app.Status.Conditions,
-			metav1.Condition{
-				Type:    "Progressing",
-				Status:  metav1.ConditionUnknown,
-				Reason:  "DeploymentNotFound",
-				Message: "Deployment not found",
-			})
-		app.Status.ReadyReplicas = 0
+	var canary *appsv1.Deployment
+	if app.Spec.Rollout != nil {
+		var deployment appsv1.Deployment
+		err := r.Client.Get(ctx, types.NamespacedName{Name: canaryName(app), Namespace: app.Namespace}, &deployment)
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		if err == nil && isOwnedBy(&deployment, app) {
+			canary = &deployment
+		}
+	}
+
+	httpRouteAvailable := false
+	if exposedThroughHTTPRoute(app) {
+		installed, err := r.isKindInstalled(httpRouteGVK)
+		if err != nil {
+			return err
+		}
+		httpRouteAvailable = installed
+	}
+
+	previous := app.Status.Rollout
+	current := advanceRollout(app, stable, canary, httpRouteAvailable, time.Now())
+	app.Status.Rollout = current
+	if previous == nil || current == nil || previous.Phase == current.Phase && previous.CanaryImage == current.CanaryImage &&
+		equality.Semantic.DeepEqual(previous.CurrentStep, current.CurrentStep) {
+		return nil
+	}
+
+	switch current.Phase {
+	case spinv1alpha1.RolloutPhaseAborted:
+		r.Recorder.Event(app, "Warning", "RolloutAborted", current.Message)
+	case spinv1alpha1.RolloutPhaseProgressing:
+		r.Recorder.Event(app, "Normal", "RolloutProgressing", current.Message)
+	case spinv1alpha1.RolloutPhasePromoting:
+		r.Recorder.Event(app, "Normal", "RolloutPromoting", current.Message)
+	case spinv1alpha1.RolloutPhaseStable:
+		r.Recorder.Event(app, "Normal", "RolloutCompleted", current.Message)
+	}
+
+	return nil
+}
+
+// reconcileRevisions records a SpinAppRevision when the configuration of a
+// SpinApp differs from its latest revision, prunes revisions beyond
+// SpinAppRevisionHistoryLimit, and returns the number of the current revision.
+func (r *SpinAppReconciler) reconcileRevisions(ctx context.Context, app *spinv1alpha1.SpinApp, runtimeConfigSecretName string) (int64, error) {
+	log := logging.FromContext(ctx)
+
+	revisions, err := r.listRevisions(ctx, app)
+	if err != nil {
+		return 0, err
+	}
+
+	snapshot := revisionSnapshot(app, runtimeConfigSecretName)
+	var current int64
+	if len(revisions) > 0 && equality.Semantic.DeepEqual(revisions[0].Spec, snapshot) {
+		current = revisions[0].Revision
 	} else {
-		deploymentConditions := deployment.Status.Conditions
-		for _, dc := range deploymentConditions {
-			if dc.Type == appsv1.DeploymentAvailable {
-				meta.SetStatusCondition(
-					&app.Status.Conditions,
-					metav1.Condition{
-						Type:    "Available",
-						Status:  metav1.ConditionStatus(dc.Status),
-						Reason:  dc.Reason,
-						Message: dc.Message,
-					})
-			}
-			if dc.Type == appsv1.DeploymentProgressing {
-				meta.SetStatusCondition(
-					&app.Status.Conditions,
-					metav1.Condition{
-						Type:    "Progressing",
-						Status:  metav1.ConditionStatus(dc.Status),
-						Reason:  dc.Reason,
-						Message: dc.Message,
-					})
-			}
+		current = 1
+		if len(revisions) > 0 {
+			current = revisions[0].Revision + 1
+		}
+
+		revision := constructRevision(app, current, snapshot)
+		if err := ctrl.SetControllerReference(app, revision, r.Scheme); err != nil {
+			return 0, err
+		}
+		log.Debug("Recording SpinAppRevision", "revision", current)
+		if err := r.Client.Create(ctx, revision); err != nil {
+			return 0, fmt.Errorf("failed to create SpinAppRevision %s: %w", revision.Name, err)
 		}
-		app.Status.ReadyReplicas = deployment.Status.ReadyReplicas
+		revisions = append([]spinv1alpha1.SpinAppRevision{*revision}, revisions...)
 	}
 
-	if err := r.Client.Status().Update(ctx, app); err != nil {
-		log.Error(err, "Unable to update status")
+	// The current revision is always retained
+	for _, revision := range revisions[min(max(r.SpinAppRevisionHistoryLimit, 1), len(revisions)):] {
+		log.Debug("Deleting old SpinAppRevision", "revision", revision.Revision)
+		if err := r.Client.Delete(ctx, &revision); client.IgnoreNotFound(err) != nil {
+			return 0, fmt.Errorf("failed to delete SpinAppRevision %s: %w", revision.Name, err)
+		}
+	}
+
+	return current, nil
+}
+
+// listRevisions returns the SpinAppRevisions of a SpinApp, newest first.
+func (r *SpinAppReconciler) listRevisions(ctx context.Context, app *spinv1alpha1.SpinApp) ([]spinv1alpha1.SpinAppRevision, error) {
+	var list spinv1alpha1.SpinAppRevisionList
+	if err := r.Client.List(ctx, &list,
+		client.InNamespace(app.Namespace),
+		client.MatchingLabels{spinapp.NameLabelKey: app.Name}); err != nil {
+		return nil, fmt.Errorf("failed to list SpinAppRevisions: %w", err)
 	}
 
-	// Re-fetch app to avoid "object has been modified" errors
-	if err := r.Client.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, app); err != nil {
-		log.Error(err, "Unable to re-fetch app")
+	revisions := slices.DeleteFunc(list.Items, func(revision spinv1alpha1.SpinAppRevision) bool {
+		return !isOwnedBy(&revision, app)
+	})
+	slices.SortFunc(revisions, func(a, b spinv1alpha1.SpinAppRevision) int {
+		return cmp.Compare(b.Revision, a.Revision)
+	})
+	return revisions, nil
+}
+
+// rollback restores the SpinAppRevision referenced by spec.rollbackTo of a
+// SpinApp and clears the field. Unknown revisions are reported as an event.
+func (r *SpinAppReconciler) rollback(ctx context.Context, app *spinv1alpha1.SpinApp) error {
+	log := logging.FromContext(ctx)
+
+	target := *app.Spec.RollbackTo
+	app.Spec.RollbackTo = nil
+
+	var revision spinv1alpha1.SpinAppRevision
+	err := r.Client.Get(ctx, types.NamespacedName{Name: revisionName(app, target), Namespace: app.Namespace}, &revision)
+	if client.IgnoreNotFound(err) != nil {
 		return err
 	}
 
-	return nil
+	if apierrors.IsNotFound(err) || !isOwnedBy(&revision, app) {
+		log.Info("Unable to roll back to unknown revision", "revision", target)
+		r.Recorder.Event(app, "Warning", "RollbackRevisionNotFound",
+			fmt.Sprintf("Revision %d not found, the app was not rolled back", target))
+	} else {
+		applyRevision(app, &revision)
+		r.Recorder.Event(app, "Normal", "RolledBack", fmt.Sprintf("Rolled back to revision %d", target))
+	}
+
+	return r.Client.Update(ctx, app)
 }
 
 const defaultCASecretName = "spin-ca"
@@ -256,14 +548,15 @@ func (r *SpinAppReconciler) ensureCASecret(ctx context.Context, caSecretName, na
 }
 
 // reconcileDeployment creates a deployment if one does not exist and reconciles it if it does.
-func (r *SpinAppReconciler) reconcileDeployment(ctx context.Context, app *spinv1alpha1.SpinApp, config *spinv1alpha1.ExecutorDeploymentConfig) error {
+// It returns the name of the runtime config secret generated for the app, if any.
+func (r *SpinAppReconciler) reconcileDeployment(ctx context.Context, app *spinv1alpha1.SpinApp, config *spinv1alpha1.ExecutorDeploymentConfig) (string, error) {
 	log := logging.FromContext(ctx).WithValues("deployment", app.Name)
 
 	rcBuilder := runtimeconfig.NewBuilder(r.Client)
 
 	generatedRuntimeConfig, err := rcBuilder.Build(ctx, app)
 	if err != nil {
-		return fmt.Errorf("failed to construct RuntimeConfig: %w", err)
+		return "", fmt.Errorf("%w: %w", errRuntimeConfigNotRendered, err)
 	}
 
 	var generatedRuntimeConfigSecretName string
@@ -271,7 +564,7 @@ func (r *SpinAppReconciler) reconcileDeployment(ctx context.Context, app *spinv1
 	if generatedRuntimeConfig != nil {
 		tomlValue, err := toml.Marshal(generatedRuntimeConfig)
 		if err != nil {
-			return fmt.Errorf("failed to marshal RuntimeConfig: %w", err)
+			return "", fmt.Errorf("failed to marshal RuntimeConfig: %w", err)
 		}
 
 		// A checksum of the rendered runtimeConfig acts as a unique-enough value to
@@ -297,13 +590,13 @@ func (r *SpinAppReconciler) reconcileDeployment(ctx context.Context, app *spinv1
 		}
 		err = controllerutil.SetOwnerReference(app, secret, r.Scheme)
 		if err != nil {
-			return fmt.Errorf("failed to set runtimeconfig owner reference: %w", err)
+			return "", fmt.Errorf("failed to set runtimeconfig owner reference: %w", err)
 		}
 
 		err = r.Client.Create(ctx, secret)
 		if err != nil {
 			if client.IgnoreAlreadyExists(err) != nil {
-				return fmt.Errorf("failed to create RuntimeConfig secret: %w", err)
+				return "", fmt.Errorf("failed to create RuntimeConfig secret: %w", err)
 			}
 			log.Debug("RuntimeConfig Secret already exists", "runtime_config_secret_name", secret.ObjectMeta.Name)
 		}
@@ -315,37 +608,141 @@ func (r *SpinAppReconciler) reconcileDeployment(ctx context.Context, app *spinv1
 			caSecretName = defaultCASecretName
 		}
 		if err := r.ensureCASecret(ctx, caSecretName, app.Namespace); err != nil {
-			return fmt.Errorf("unable to create default ca-certificate secret: %w", err)
+			return "", fmt.Errorf("unable to create default ca-certificate secret: %w", err)
 		}
 	}
 
-	desiredDeployment, err := constructDeployment(ctx, app, config, generatedRuntimeConfigSecretName, caSecretName, r.Scheme)
+	desiredDeployment, desiredCanary, err := constructRolloutDeployments(ctx, app, config, generatedRuntimeConfigSecretName, caSecretName, r.Scheme)
 	if err != nil {
-		return fmt.Errorf("failed to construct Deployment: %w", err)
+		return "", fmt.Errorf("failed to construct Deployment: %w", err)
+	}
+
+	// Values sourced from Secrets and ConfigMaps (variables and user-provided
+	// runtime config) are only read when a pod starts. Stamping a checksum of
+	// their contents on the pod template rolls the pods when they change.
+	checksum, err := dependencyChecksum(ctx, r.Client, app)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute dependency checksum: %w", err)
+	}
+	desiredDeployments := []*appsv1.Deployment{desiredDeployment}
+	if desiredCanary != nil {
+		desiredDeployments = append(desiredDeployments, desiredCanary)
+	}
+	if checksum != "" {
+		for _, deployment := range desiredDeployments {
+			deployment.Spec.Template.Annotations[spinapp.ConfigChecksumAnnotationKey] = checksum
+		}
 	}
 
 	log.Debug("Reconciling Deployment")
 
-	// We want to use server-side apply https://kubernetes.io/docs/reference/using-api/server-side-apply
-	patchMethod := client.Apply
-	patchOptions := &client.PatchOptions{
-		Force:        generics.Ptr(true), // Force b/c any fields we are setting need to be owned by the spin-operator
-		FieldManager: FieldManager,
+	for _, deployment := range desiredDeployments {
+		if err := r.applyChildResource(ctx, deployment); err != nil {
+			log.Error(err, "Unable to reconcile Deployment", "name", deployment.Name)
+			return "", err
+		}
 	}
 
-	// Note that we reconcile even if the deployment is in a good state. We rely on controller-runtime to rate limit us.
-	if err := r.Client.Patch(ctx, desiredDeployment, patchMethod, patchOptions); err != nil {
-		log.Error(err, "Unable to reconcile Deployment")
-		return err
+	if err := r.pruneRuntimeConfigSecrets(ctx, app, generatedRuntimeConfigSecretName); err != nil {
+		log.Error(err, "Unable to prune stale RuntimeConfig secrets")
+		return "", err
+	}
+
+	return generatedRuntimeConfigSecretName, nil
+}
+
+// pruneRuntimeConfigSecrets deletes generated runtime config secrets that are
+// no longer needed by an app. The current secret and any secret mounted by a
+// ReplicaSet with running pods are always kept, as are up to
+// RuntimeConfigHistoryLimit of the most recent secrets referenced by older
+// ReplicaSets so that deployment rollbacks keep working.
+func (r *SpinAppReconciler) pruneRuntimeConfigSecrets(ctx context.Context, app *spinv1alpha1.SpinApp, currentSecretName string) error {
+	log := logging.FromContext(ctx)
+
+	var secrets corev1.SecretList
+	if err := r.Client.List(ctx, &secrets,
+		client.InNamespace(app.Namespace),
+		client.MatchingLabels{spinapp.NameLabelKey: app.Name}); err != nil {
+		return fmt.Errorf("failed to list RuntimeConfig secrets: %w", err)
+	}
+
+	var replicaSets appsv1.ReplicaSetList
+	if err := r.Client.List(ctx, &replicaSets,
+		client.InNamespace(app.Namespace),
+		client.MatchingLabels{spinapp.NameLabelKey: app.Name}); err != nil {
+		return fmt.Errorf("failed to list ReplicaSets: %w", err)
+	}
+
+	// Newest ReplicaSets first, so history is retained for the most recent revisions.
+	slices.SortFunc(replicaSets.Items, func(a, b appsv1.ReplicaSet) int {
+		return b.CreationTimestamp.Compare(a.CreationTimestamp.Time)
+	})
+
+	keep := map[string]struct{}{currentSecretName: {}}
+	history := 0
+	for _, rs := range replicaSets.Items {
+		secretName := runtimeConfigSecretNameForPodSpec(&rs.Spec.Template.Spec)
+		if _, ok := keep[secretName]; ok || secretName == "" {
+			continue
+		}
+
+		running := rs.Status.Replicas > 0 || (rs.Spec.Replicas != nil && *rs.Spec.Replicas > 0)
+		if running || history < r.RuntimeConfigHistoryLimit {
+			keep[secretName] = struct{}{}
+			if !running {
+				history++
+			}
+		}
+	}
+
+	for _, secret := range secrets.Items {
+		if _, ok := keep[secret.Name]; ok {
+			continue
+		}
+		// Only consider secrets that were generated for this app.
+		if !isOwnedBy(&secret, app) {
+			continue
+		}
+
+		log.Debug("Deleting stale RuntimeConfig secret", "runtime_config_secret_name", secret.Name)
+		if err := r.Client.Delete(ctx, &secret); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete RuntimeConfig secret %s: %w", secret.Name, err)
+		}
 	}
 
 	return nil
 }
 
+// runtimeConfigSecretNameForPodSpec returns the name of the runtime config
+// secret mounted by a pod spec, or an empty string if there is none.
+func runtimeConfigSecretNameForPodSpec(spec *corev1.PodSpec) string {
+	for _, volume := range spec.Volumes {
+		if volume.Name == runtimeConfigVolumeName && volume.Secret != nil {
+			return volume.Secret.SecretName
+		}
+	}
+	return ""
+}
+
+// isOwnedBy returns whether obj has an owner reference to owner.
+func isOwnedBy(obj metav1.Object, owner metav1.Object) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == owner.GetUID() {
+			return true
+		}
+	}
+	return false
+}
+
 // reconcileService creates a service if one does not exist and updates it if it does.
+// The service is removed when the app opts out of having one.
 func (r *SpinAppReconciler) reconcileService(ctx context.Context, app *spinv1alpha1.SpinApp) error {
 	log := logging.FromContext(ctx).WithValues("service", app.Name)
 
+	if skipService(app) {
+		return r.deleteService(ctx, app)
+	}
+
 	desiredService := constructService(app)
 	if err := ctrl.SetControllerReference(app, desiredService, r.Scheme); err != nil {
 		log.Error(err, "Unable to construct Service")
@@ -354,21 +751,340 @@ func (r *SpinAppReconciler) reconcileService(ctx context.Context, app *spinv1alp
 
 	log.Debug("Reconciling Service")
 
-	// We want to use server-side apply https://kubernetes.io/docs/reference/using-api/server-side-apply
-	patchMethod := client.Apply
-	patchOptions := &client.PatchOptions{
+	if err := r.applyChildResource(ctx, desiredService); err != nil {
+		log.Error(err, "Unable to reconcile Service")
+		return err
+	}
+
+	if usesCanaryService(app) {
+		canaryService := constructCanaryService(app)
+		if err := ctrl.SetControllerReference(app, canaryService, r.Scheme); err != nil {
+			log.Error(err, "Unable to construct canary Service")
+			return err
+		}
+		if err := r.applyChildResource(ctx, canaryService); err != nil {
+			log.Error(err, "Unable to reconcile canary Service")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteCanary deletes the canary Deployment and Service of a SpinApp once
+// they are no longer needed by a progressive rollout.
+func (r *SpinAppReconciler) deleteCanary(ctx context.Context, app *spinv1alpha1.SpinApp) error {
+	key := types.NamespacedName{Name: canaryName(app), Namespace: app.Namespace}
+
+	var objects []client.Object
+	if !rolloutInProgress(app) {
+		objects = append(objects, &appsv1.Deployment{})
+	}
+	if !usesCanaryService(app) {
+		objects = append(objects, &corev1.Service{})
+	}
+
+	for _, obj := range objects {
+		if err := r.Client.Get(ctx, key, obj); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			continue
+		}
+		if !isOwnedBy(obj, app) {
+			continue
+		}
+		if err := r.Client.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteService deletes the Service created for a SpinApp. Services that
+// weren't created by the operator are left alone.
+func (r *SpinAppReconciler) deleteService(ctx context.Context, app *spinv1alpha1.SpinApp) error {
+	var svc corev1.Service
+	err := r.Client.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, &svc)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if !isOwnedBy(&svc, app) {
+		return nil
+	}
+
+	return client.IgnoreNotFound(r.Client.Delete(ctx, &svc))
+}
+
+// applyChildResource creates or updates a child resource of a SpinApp with
+// server-side apply https://kubernetes.io/docs/reference/using-api/server-side-apply
+//
+// Note that we reconcile even if the resource is in a good state. We rely on
+// controller-runtime to rate limit us.
+func (r *SpinAppReconciler) applyChildResource(ctx context.Context, obj client.Object) error {
+	return r.Client.Patch(ctx, obj, client.Apply, &client.PatchOptions{
 		Force:        generics.Ptr(true), // Force b/c any fields we are setting need to be owned by the spin-operator
 		FieldManager: FieldManager,
+	})
+}
+
+// reconcilePodDisruptionBudget creates or updates the PodDisruptionBudget of a
+// SpinApp when it has a disruption budget, and removes it otherwise.
+func (r *SpinAppReconciler) reconcilePodDisruptionBudget(ctx context.Context, app *spinv1alpha1.SpinApp, executor *spinv1alpha1.SpinAppExecutor) error {
+	log := logging.FromContext(ctx).WithValues("pdb", app.Name)
+
+	budget := resolveDisruptionBudget(app, r.DefaultDisruptionBudget)
+	if !executor.Spec.CreateDeployment || budget == nil {
+		return r.deletePodDisruptionBudget(ctx, app)
 	}
-	// Note that we reconcile even if the service is in a good state. We rely on controller-runtime to rate limit us.
-	if err := r.Client.Patch(ctx, desiredService, patchMethod, patchOptions); err != nil {
-		log.Error(err, "Unable to reconcile Service")
+
+	desiredPDB := constructPodDisruptionBudget(app, budget)
+	if err := ctrl.SetControllerReference(app, desiredPDB, r.Scheme); err != nil {
+		log.Error(err, "Unable to construct PodDisruptionBudget")
+		return err
+	}
+
+	log.Debug("Reconciling PodDisruptionBudget")
+
+	if err := r.applyChildResource(ctx, desiredPDB); err != nil {
+		log.Error(err, "Unable to reconcile PodDisruptionBudget")
+		return err
+	}
+
+	return nil
+}
+
+// deletePodDisruptionBudget deletes the PodDisruptionBudget created for a
+// SpinApp. Budgets that weren't created by the operator are left alone.
+func (r *SpinAppReconciler) deletePodDisruptionBudget(ctx context.Context, app *spinv1alpha1.SpinApp) error {
+	var pdb policyv1.PodDisruptionBudget
+	err := r.Client.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, &pdb)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if !isOwnedBy(&pdb, app) {
+		return nil
+	}
+
+	return client.IgnoreNotFound(r.Client.Delete(ctx, &pdb))
+}
+
+// reconcileIngress creates or updates the Ingress of a SpinApp when one is
+// configured, and removes it otherwise.
+func (r *SpinAppReconciler) reconcileIngress(ctx context.Context, app *spinv1alpha1.SpinApp) error {
+	log := logging.FromContext(ctx).WithValues("ingress", app.Name)
+
+	if app.Spec.Exposure == nil || app.Spec.Exposure.Ingress == nil {
+		return r.deleteIngress(ctx, app)
+	}
+
+	desiredIngress := constructIngress(app)
+	if err := ctrl.SetControllerReference(app, desiredIngress, r.Scheme); err != nil {
+		log.Error(err, "Unable to construct Ingress")
+		return err
+	}
+
+	log.Debug("Reconciling Ingress")
+
+	if err := r.applyChildResource(ctx, desiredIngress); err != nil {
+		log.Error(err, "Unable to reconcile Ingress")
 		return err
 	}
 
 	return nil
 }
 
+// deleteIngress deletes the Ingress created for a SpinApp. Ingresses that
+// weren't created by the operator are left alone.
+func (r *SpinAppReconciler) deleteIngress(ctx context.Context, app *spinv1alpha1.SpinApp) error {
+	var ingress networkingv1.Ingress
+	err := r.Client.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, &ingress)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if !isOwnedBy(&ingress, app) {
+		return nil
+	}
+
+	return client.IgnoreNotFound(r.Client.Delete(ctx, &ingress))
+}
+
+// reconcileHTTPRoute creates or updates the Gateway API HTTPRoute of a SpinApp
+// when one is configured, and removes it otherwise. It returns
+// errGatewayAPINotInstalled when the HTTPRoute CRD isn't available.
+func (r *SpinAppReconciler) reconcileHTTPRoute(ctx context.Context, app *spinv1alpha1.SpinApp) error {
+	log := logging.FromContext(ctx).WithValues("httproute", app.Name)
+
+	if !exposedThroughHTTPRoute(app) {
+		return r.deleteOptionalResource(ctx, app, httpRouteGVK)
+	}
+
+	installed, err := r.isKindInstalled(httpRouteGVK)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		log.Info("An HTTPRoute is configured but the Gateway API is not installed")
+		return errGatewayAPINotInstalled
+	}
+
+	desiredRoute := constructHTTPRoute(app)
+	if err := ctrl.SetControllerReference(app, desiredRoute, r.Scheme); err != nil {
+		log.Error(err, "Unable to construct HTTPRoute")
+		return err
+	}
+
+	log.Debug("Reconciling HTTPRoute")
+
+	if err := r.applyChildResource(ctx, desiredRoute); err != nil {
+		log.Error(err, "Unable to reconcile HTTPRoute")
+		return err
+	}
+
+	return nil
+}
+
+// reconcileHorizontalPodAutoscaler creates or updates the HorizontalPodAutoscaler
+// of a SpinApp when autoscaling is configured, and removes it otherwise.
+func (r *SpinAppReconciler) reconcileHorizontalPodAutoscaler(ctx context.Context, app *spinv1alpha1.SpinApp, executor *spinv1alpha1.SpinAppExecutor) error {
+	log := logging.FromContext(ctx).WithValues("hpa", app.Name)
+
+	// KEDA manages its own HorizontalPodAutoscaler
+	if !usesManagedAutoscaling(app, executor) || app.Spec.Autoscaling.KEDA != nil {
+		return r.deleteHorizontalPodAutoscaler(ctx, app)
+	}
+
+	desiredHPA := constructHorizontalPodAutoscaler(app)
+	if err := ctrl.SetControllerReference(app, desiredHPA, r.Scheme); err != nil {
+		log.Error(err, "Unable to construct HorizontalPodAutoscaler")
+		return err
+	}
+
+	log.Debug("Reconciling HorizontalPodAutoscaler")
+
+	if err := r.applyChildResource(ctx, desiredHPA); err != nil {
+		log.Error(err, "Unable to reconcile HorizontalPodAutoscaler")
+		return err
+	}
+
+	return nil
+}
+
+// deleteHorizontalPodAutoscaler deletes the HorizontalPodAutoscaler created for
+// a SpinApp. Autoscalers that weren't created by the operator are left alone.
+func (r *SpinAppReconciler) deleteHorizontalPodAutoscaler(ctx context.Context, app *spinv1alpha1.SpinApp) error {
+	var hpa autoscalingv2.HorizontalPodAutoscaler
+	err := r.Client.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, &hpa)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if !isOwnedBy(&hpa, app) {
+		return nil
+	}
+
+	return client.IgnoreNotFound(r.Client.Delete(ctx, &hpa))
+}
+
+// reconcileScaledObject creates or updates the KEDA ScaledObject of a SpinApp
+// when KEDA autoscaling is configured, and removes it otherwise. It returns
+// errKEDANotInstalled when the ScaledObject CRD isn't available.
+func (r *SpinAppReconciler) reconcileScaledObject(ctx context.Context, app *spinv1alpha1.SpinApp, executor *spinv1alpha1.SpinAppExecutor) error {
+	log := logging.FromContext(ctx).WithValues("scaledobject", app.Name)
+
+	if !usesKEDAAutoscaling(app, executor) {
+		return r.deleteScaledObject(ctx, app)
+	}
+
+	installed, err := r.isKindInstalled(scaledObjectGVK)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		log.Info("KEDA autoscaling is configured but KEDA is not installed")
+		return errKEDANotInstalled
+	}
+
+	desiredScaledObject, err := constructScaledObject(app)
+	if err != nil {
+		return err
+	}
+	if err := ctrl.SetControllerReference(app, desiredScaledObject, r.Scheme); err != nil {
+		log.Error(err, "Unable to construct ScaledObject")
+		return err
+	}
+
+	log.Debug("Reconciling ScaledObject")
+
+	if err := r.applyChildResource(ctx, desiredScaledObject); err != nil {
+		log.Error(err, "Unable to reconcile ScaledObject")
+		return err
+	}
+
+	return nil
+}
+
+// deleteScaledObject deletes the KEDA ScaledObject created for a SpinApp, if
+// KEDA is installed. ScaledObjects that weren't created by the operator are
+// left alone.
+func (r *SpinAppReconciler) deleteScaledObject(ctx context.Context, app *spinv1alpha1.SpinApp) error {
+	return r.deleteOptionalResource(ctx, app, scaledObjectGVK)
+}
+
+// deleteOptionalResource deletes the resource of a kind served by an optional
+// CRD (e.g KEDA or the Gateway API) that was created for a SpinApp. Nothing is
+// deleted if the CRD isn't installed, or the resource wasn't created by the
+// operator.
+func (r *SpinAppReconciler) deleteOptionalResource(ctx context.Context, app *spinv1alpha1.SpinApp, gvk schema.GroupVersionKind) error {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	err := r.Client.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, obj)
+	if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !isOwnedBy(obj, app) {
+		return nil
+	}
+
+	return client.IgnoreNotFound(r.Client.Delete(ctx, obj))
+}
+
+// isKindInstalled returns whether a kind of an optional CRD is served by the
+// cluster. The REST mapper re-runs discovery for kinds it doesn't know yet, so
+// CRDs installed after the operator started are picked up.
+func (r *SpinAppReconciler) isKindInstalled(gvk schema.GroupVersionKind) (bool, error) {
+	_, err := r.Client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// usesManagedAutoscaling returns whether the operator manages an autoscaler
+// (an HPA or a KEDA ScaledObject) for a SpinApp. Suspended apps have none, so
+// that nothing scales them back up.
+func usesManagedAutoscaling(app *spinv1alpha1.SpinApp, executor *spinv1alpha1.SpinAppExecutor) bool {
+	return executor.Spec.CreateDeployment && app.Spec.EnableAutoscaling && app.Spec.Autoscaling != nil &&
+		!app.Spec.Suspend
+}
+
+// usesKEDAAutoscaling returns whether the operator manages a KEDA ScaledObject
+// for a SpinApp.
+func usesKEDAAutoscaling(app *spinv1alpha1.SpinApp, executor *spinv1alpha1.SpinAppExecutor) bool {
+	return usesManagedAutoscaling(app, executor) && app.Spec.Autoscaling.KEDA != nil
+}
+
 // deleteDeployment deletes the deployment for a SpinApp.
 func (r *SpinAppReconciler) deleteDeployment(ctx context.Context, app *spinv1alpha1.SpinApp) error {
 	deployment, err := r.findDeploymentForApp(ctx, app)
@@ -387,12 +1103,19 @@ func (r *SpinAppReconciler) deleteDeployment(ctx context.Context, app *spinv1alp
 // constructDeployment builds an appsv1.Deployment based on the configuration of a SpinApp.
 func constructDeployment(ctx context.Context, app *spinv1alpha1.SpinApp, config *spinv1alpha1.ExecutorDeploymentConfig,
 	generatedRuntimeConfigSecretName, caSecretName string, scheme *runtime.Scheme) (*appsv1.Deployment, error) {
-	// TODO: Once we land admission webhooks write some validation to make
-	// replicas and enableAutoscaling mutually exclusive.
+	// With autoscaling enabled the replica count is only ours to set when the
+	// autoscaler targets the SpinApp through its scale subresource, as the
+	// autoscalers managed by the operator do and external ones declare with
+	// spec.autoscalerTarget. Otherwise the autoscaler targets the deployment
+	// directly.
+	//
+	// Suspended apps are scaled to zero. spec.replicas is left as is, so the
+	// replica count or autoscaling is restored when the app is resumed.
 	var replicas *int32
-	if app.Spec.EnableAutoscaling {
-		replicas = nil
-	} else {
+	if app.Spec.Suspend {
+		replicas = generics.Ptr(int32(0))
+	} else if !app.Spec.EnableAutoscaling || managedAutoscalerTargetsApp(app) ||
+		app.Spec.AutoscalerTarget == spinv1alpha1.AutoscalerTargetSpinApp {
 		replicas = generics.Ptr(app.Spec.Replicas)
 	}
 
@@ -405,16 +1128,12 @@ func constructDeployment(ctx context.Context, app *spinv1alpha1.SpinApp, config
 	if annotations == nil {
 		annotations = map[string]string{}
 	}
-	templateAnnotations := app.Spec.PodAnnotations
+	templateAnnotations := maps.Clone(app.Spec.PodAnnotations)
 	if templateAnnotations == nil {
 		templateAnnotations = map[string]string{}
 	}
 
-	statusKey, statusValue := spinapp.ConstructStatusReadyLabel(app.Name)
-	readyLabels := map[string]string{
-		spinapp.NameLabelKey: app.Name,
-		statusKey:            statusValue,
-	}
+	readyLabels := constructPodSelectorLabels(app)
 
 	templateLabels := app.Spec.PodLabels
 	if templateLabels == nil {
@@ -429,7 +1148,9 @@ func constructDeployment(ctx context.Context, app *spinv1alpha1.SpinApp, config
 		Requests: app.Spec.Resources.Requests,
 	}
 
-	env := ConstructEnvForApp(ctx, app, spinapp.DefaultHTTPPort, config.Otel)
+	listenPort := resolveListenPort(app, config)
+
+	env := ConstructEnvForApp(ctx, app, int(listenPort), config.Otel)
 	if app.Spec.Components != nil {
 		env = append(env, corev1.EnvVar{
 			Name:  "SPIN_COMPONENTS_TO_RETAIN",
@@ -437,13 +1158,33 @@ func constructDeployment(ctx context.Context, app *spinv1alpha1.SpinApp, config
 		})
 	}
 
-	readinessProbe, livenessProbe, err := ConstructPodHealthChecks(app)
+	readinessProbe, livenessProbe, err := ConstructPodHealthChecks(app, listenPort)
 	if err != nil {
 		return nil, err
 	}
 
 	labels := constructAppLabels(app)
 
+	scheduling := mergePodScheduling(app, config)
+	rollout := mergeRolloutControls(app, config)
+	var strategy appsv1.DeploymentStrategy
+	if rollout.Strategy != nil {
+		strategy = *rollout.Strategy
+	}
+	var minReadySeconds int32
+	if rollout.MinReadySeconds != nil {
+		minReadySeconds = *rollout.MinReadySeconds
+	}
+
+	podSecurityContext, err := mergePodSecurityContext(app, config)
+	if err != nil {
+		return nil, err
+	}
+	securityContext, err := mergeSecurityContext(app, config)
+	if err != nil {
+		return nil, err
+	}
+
 	var container corev1.Container
 	if config.RuntimeClassName != nil {
 		container = corev1.Container{
@@ -452,16 +1193,17 @@ func constructDeployment(ctx context.Context, app *spinv1alpha1.SpinApp, config
 			Command: []string{"/"},
 			Ports: []corev1.ContainerPort{{
 				Name:          spinapp.HTTPPortName,
-				ContainerPort: spinapp.DefaultHTTPPort,
+				ContainerPort: listenPort,
 			}},
-			Env:            env,
-			VolumeMounts:   volumeMounts,
-			Resources:      resources,
-			LivenessProbe:  livenessProbe,
-			ReadinessProbe: readinessProbe,
+			Env:             env,
+			VolumeMounts:    volumeMounts,
+			Resources:       resources,
+			LivenessProbe:   livenessProbe,
+			ReadinessProbe:  readinessProbe,
+			SecurityContext: securityContext,
 		}
 	} else if config.SpinImage != nil {
-		args := []string{"up", "--listen", fmt.Sprintf("0.0.0.0:%d", spinapp.DefaultHTTPPort), "-f", app.Spec.Image, "--runtime-config-file", "/runtime-config.toml"}
+		args := []string{"up", "--listen", fmt.Sprintf("0.0.0.0:%d", listenPort), "-f", app.Spec.Image, "--runtime-config-file", "/runtime-config.toml"}
 		if app.Spec.Components != nil {
 			for _, component := range app.Spec.Components {
 				args = append(args, "--component-id", component)
@@ -474,13 +1216,14 @@ func constructDeployment(ctx context.Context, app *spinv1alpha1.SpinApp, config
 			Args: args,
 			Ports: []corev1.ContainerPort{{
 				Name:          spinapp.HTTPPortName,
-				ContainerPort: spinapp.DefaultHTTPPort,
+				ContainerPort: listenPort,
 			}},
-			Env:            env,
-			VolumeMounts:   volumeMounts,
-			Resources:      resources,
-			LivenessProbe:  livenessProbe,
-			ReadinessProbe: readinessProbe,
+			Env:             env,
+			VolumeMounts:    volumeMounts,
+			Resources:       resources,
+			LivenessProbe:   livenessProbe,
+			ReadinessProbe:  readinessProbe,
+			SecurityContext: securityContext,
 		}
 	} else {
 		return nil, errors.New("must specify either runtimeClassName or spinImage")
@@ -502,16 +1245,28 @@ func constructDeployment(ctx context.Context, app *spinv1alpha1.SpinApp, config
 			Selector: &metav1.LabelSelector{
 				MatchLabels: readyLabels,
 			},
+			Strategy:                strategy,
+			MinReadySeconds:         minReadySeconds,
+			ProgressDeadlineSeconds: rollout.ProgressDeadlineSeconds,
+			RevisionHistoryLimit:    rollout.RevisionHistoryLimit,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels:      templateLabels,
 					Annotations: templateAnnotations,
 				},
 				Spec: corev1.PodSpec{
-					RuntimeClassName: config.RuntimeClassName,
-					Containers:       []corev1.Container{container},
-					ImagePullSecrets: app.Spec.ImagePullSecrets,
-					Volumes:          volumes,
+					RuntimeClassName:             config.RuntimeClassName,
+					Containers:                   []corev1.Container{container},
+					ImagePullSecrets:             app.Spec.ImagePullSecrets,
+					Volumes:                      volumes,
+					ServiceAccountName:           app.Spec.ServiceAccountName,
+					AutomountServiceAccountToken: app.Spec.AutomountServiceAccountToken,
+					SecurityContext:              podSecurityContext,
+					NodeSelector:                 scheduling.NodeSelector,
+					Tolerations:                  scheduling.Tolerations,
+					Affinity:                     scheduling.Affinity,
+					TopologySpreadConstraints:    scheduling.TopologySpreadConstraints,
+					PriorityClassName:            scheduling.PriorityClassName,
 				},
 			},
 		},
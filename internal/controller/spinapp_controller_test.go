@@ -12,17 +12,23 @@ import (
 
 	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
 	"github.com/spinkube/spin-operator/internal/generics"
+	"github.com/spinkube/spin-operator/pkg/spinapp"
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	clientscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	controllerconfig "sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -110,14 +116,26 @@ func setupController(t *testing.T) (*envTestState, ctrl.Manager, *SpinAppReconci
 
 	require.NoError(t, err)
 
+	// The reconciler uses the manager's cached client, like it does in
+	// production, so that it can make use of field indexes.
 	ctrlr := &SpinAppReconciler{
-		Client:   envTest.k8sClient,
+		Client:   mgr.GetClient(),
 		Scheme:   envTest.scheme,
 		Recorder: mgr.GetEventRecorderFor("spinapp-reconciler"),
 	}
 
 	require.NoError(t, ctrlr.SetupWithManager(mgr))
 
+	// The executor reconciler registers the spec.executor field index that the
+	// SpinApp reconciler depends on.
+	executorCtrlr := &SpinAppExecutorReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   envTest.scheme,
+		Recorder: mgr.GetEventRecorderFor("spinappexecutor-reconciler"),
+	}
+
+	require.NoError(t, executorCtrlr.SetupWithManager(mgr))
+
 	return envTest, mgr, ctrlr
 }
 
@@ -194,6 +212,147 @@ func TestReconcile_Integration_Deployment_Respects_Executor_Config(t *testing.T)
 	wg.Wait()
 }
 
+func TestReconcile_Integration_ExecutorChangeRollsOut(t *testing.T) {
+	t.Parallel()
+
+	envTest, mgr, _ := setupController(t)
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFunc()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		require.NoError(t, mgr.Start(ctx))
+		wg.Done()
+	}()
+
+	executor := &spinv1alpha1.SpinAppExecutor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "executor",
+			Namespace: "default",
+		},
+		Spec: spinv1alpha1.SpinAppExecutorSpec{
+			CreateDeployment: true,
+			DeploymentConfig: &spinv1alpha1.ExecutorDeploymentConfig{
+				RuntimeClassName: generics.Ptr("a-runtime-class"),
+			},
+		},
+	}
+
+	require.NoError(t, envTest.k8sClient.Create(ctx, executor))
+
+	spinApp := &spinv1alpha1.SpinApp{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+		},
+		Spec: spinv1alpha1.SpinAppSpec{
+			Executor: "executor",
+			Image:    "ghcr.io/radu-matei/perftest:v1",
+		},
+	}
+
+	require.NoError(t, envTest.k8sClient.Create(ctx, spinApp))
+
+	var deployment appsv1.Deployment
+	require.Eventually(t, func() bool {
+		err := envTest.k8sClient.Get(ctx,
+			types.NamespacedName{
+				Namespace: "default",
+				Name:      "app"},
+			&deployment)
+		return err == nil
+	}, 3*time.Second, 100*time.Millisecond)
+
+	require.Equal(t, "a-runtime-class", *deployment.Spec.Template.Spec.RuntimeClassName)
+
+	// Update the executor without touching the app
+	require.NoError(t, envTest.k8sClient.Get(ctx,
+		types.NamespacedName{
+			Namespace: "default",
+			Name:      "executor"},
+		executor), "fetch executor to update")
+
+	executor.Spec.DeploymentConfig.RuntimeClassName = generics.Ptr("another-runtime-class")
+	require.NoError(t, envTest.k8sClient.Update(ctx, executor))
+
+	// Wait for the deployment to pick up the new runtime class
+	require.Eventually(t, func() bool {
+		err := envTest.k8sClient.Get(ctx,
+			types.NamespacedName{
+				Namespace: "default",
+				Name:      "app"},
+			&deployment)
+		return err == nil && *deployment.Spec.Template.Spec.RuntimeClassName == "another-runtime-class"
+	}, 3*time.Second, 100*time.Millisecond)
+
+	// Terminate the context to force the manager to shut down.
+	cancelFunc()
+	wg.Wait()
+}
+
+func TestReconcile_Integration_ClusterExecutor(t *testing.T) {
+	t.Parallel()
+
+	envTest, mgr, _ := setupController(t)
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		require.NoError(t, mgr.Start(ctx))
+		wg.Done()
+	}()
+
+	// Create a cluster executor and no namespaced executor
+	executor := &spinv1alpha1.ClusterSpinAppExecutor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "executor",
+		},
+		Spec: spinv1alpha1.SpinAppExecutorSpec{
+			CreateDeployment: true,
+			DeploymentConfig: &spinv1alpha1.ExecutorDeploymentConfig{
+				RuntimeClassName: generics.Ptr("a-cluster-runtime-class"),
+			},
+		},
+	}
+
+	require.NoError(t, envTest.k8sClient.Create(ctx, executor))
+
+	spinApp := &spinv1alpha1.SpinApp{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+		},
+		Spec: spinv1alpha1.SpinAppSpec{
+			Executor: "executor",
+			Image:    "ghcr.io/radu-matei/perftest:v1",
+		},
+	}
+
+	require.NoError(t, envTest.k8sClient.Create(ctx, spinApp))
+
+	// Wait for the underlying deployment to exist
+	var deployment appsv1.Deployment
+	require.Eventually(t, func() bool {
+		err := envTest.k8sClient.Get(ctx,
+			types.NamespacedName{
+				Namespace: "default",
+				Name:      "app"},
+			&deployment)
+		return err == nil
+	}, 3*time.Second, 100*time.Millisecond)
+
+	require.Equal(t, "a-cluster-runtime-class", *deployment.Spec.Template.Spec.RuntimeClassName)
+
+	// Terminate the context to force the manager to shut down.
+	cancelFunc()
+	wg.Wait()
+}
+
 func TestReconcile_Integration_RuntimeConfig(t *testing.T) {
 	t.Parallel()
 
@@ -439,6 +598,145 @@ func TestConstructDeployment_WithPodLabels(t *testing.T) {
 	require.Equal(t, deployment.Spec.Template.Labels[key], value)
 }
 
+func TestConstructDeployment_Autoscaling(t *testing.T) {
+	t.Parallel()
+
+	cfg := &spinv1alpha1.ExecutorDeploymentConfig{
+		RuntimeClassName: generics.Ptr("bananarama"),
+	}
+
+	// Autoscalers targeting the deployment directly own its replica count
+	app := minimalSpinApp()
+	app.Spec.EnableAutoscaling = true
+	app.Spec.Replicas = 0
+	deployment, err := constructDeployment(context.Background(), app, cfg, "", "", nil)
+	require.NoError(t, err)
+	require.Nil(t, deployment.Spec.Replicas)
+
+	// Autoscalers targeting the SpinApp through the scale subresource have their
+	// replica count propagated, including scaling to zero
+	app.Spec.AutoscalerTarget = spinv1alpha1.AutoscalerTargetSpinApp
+	deployment, err = constructDeployment(context.Background(), app, cfg, "", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, generics.Ptr(int32(0)), deployment.Spec.Replicas)
+
+	app.Spec.Replicas = 4
+	deployment, err = constructDeployment(context.Background(), app, cfg, "", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, generics.Ptr(int32(4)), deployment.Spec.Replicas)
+
+	// Switching back to an autoscaler targeting the deployment hands its
+	// replica count back, even though replicas was set through the scale
+	// subresource before
+	app.ManagedFields = []metav1.ManagedFieldsEntry{{
+		Manager:     "keda",
+		Operation:   metav1.ManagedFieldsOperationUpdate,
+		Subresource: "scale",
+		FieldsType:  "FieldsV1",
+		FieldsV1:    &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)},
+	}}
+	app.Spec.AutoscalerTarget = spinv1alpha1.AutoscalerTargetDeployment
+	deployment, err = constructDeployment(context.Background(), app, cfg, "", "", nil)
+	require.NoError(t, err)
+	require.Nil(t, deployment.Spec.Replicas)
+}
+
+func TestConstructDeployment_Suspend(t *testing.T) {
+	t.Parallel()
+
+	cfg := &spinv1alpha1.ExecutorDeploymentConfig{
+		RuntimeClassName: generics.Ptr("bananarama"),
+	}
+	executor := &spinv1alpha1.SpinAppExecutor{Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true}}
+
+	// Suspended apps are scaled to zero without changing their replica count
+	app := minimalSpinApp()
+	app.Spec.Replicas = 3
+	app.Spec.Suspend = true
+	deployment, err := constructDeployment(context.Background(), app, cfg, "", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, generics.Ptr(int32(0)), deployment.Spec.Replicas)
+	require.Equal(t, int32(3), app.Spec.Replicas)
+
+	// Suspended apps have no managed autoscaler that would scale them back up
+	app.Spec.Replicas = 0
+	app.Spec.EnableAutoscaling = true
+	app.Spec.Autoscaling = &spinv1alpha1.Autoscaling{MaxReplicas: 3}
+	deployment, err = constructDeployment(context.Background(), app, cfg, "", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, generics.Ptr(int32(0)), deployment.Spec.Replicas)
+	require.False(t, usesManagedAutoscaling(app, executor))
+
+	// Resuming restores the replica count the autoscaler last set on the app
+	app.Spec.Suspend = false
+	app.Spec.Replicas = 2
+	deployment, err = constructDeployment(context.Background(), app, cfg, "", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, generics.Ptr(int32(2)), deployment.Spec.Replicas)
+	require.True(t, usesManagedAutoscaling(app, executor))
+}
+
+func TestConstructDeployment_ListenPort(t *testing.T) {
+	t.Parallel()
+
+	listenAddr := func(container corev1.Container) string {
+		for _, env := range container.Env {
+			if env.Name == "SPIN_HTTP_LISTEN_ADDR" {
+				return env.Value
+			}
+		}
+		return ""
+	}
+
+	shimConfig := &spinv1alpha1.ExecutorDeploymentConfig{
+		RuntimeClassName: generics.Ptr("bananarama"),
+	}
+	spintainerConfig := &spinv1alpha1.ExecutorDeploymentConfig{
+		SpinImage: generics.Ptr("ghcr.io/fermyon/spin:v2.2.0"),
+	}
+
+	// Apps created before the listen port was configurable keep port 80
+	app := minimalSpinApp()
+	app.Spec.Checks.Readiness = &spinv1alpha1.HealthProbe{HTTPGet: &spinv1alpha1.HTTPHealthProbe{Path: "/healthz"}}
+	deployment, err := constructDeployment(context.Background(), app, shimConfig, "", "", nil)
+	require.NoError(t, err)
+	container := deployment.Spec.Template.Spec.Containers[0]
+	require.Equal(t, int32(80), container.Ports[0].ContainerPort)
+	require.Equal(t, "0.0.0.0:80", listenAddr(container))
+	require.Equal(t, intstr.FromInt(80), container.ReadinessProbe.HTTPGet.Port)
+
+	deployment, err = constructDeployment(context.Background(), app, spintainerConfig, "", "", nil)
+	require.NoError(t, err)
+	container = deployment.Spec.Template.Spec.Containers[0]
+	require.Equal(t, int32(80), container.Ports[0].ContainerPort)
+	require.Contains(t, container.Args, "0.0.0.0:80")
+
+	// The port of the executor is used when the app doesn't set one
+	spintainerConfig.ListenPort = generics.Ptr(int32(9000))
+	deployment, err = constructDeployment(context.Background(), app, spintainerConfig, "", "", nil)
+	require.NoError(t, err)
+	container = deployment.Spec.Template.Spec.Containers[0]
+	require.Equal(t, int32(9000), container.Ports[0].ContainerPort)
+	require.Equal(t, "0.0.0.0:9000", listenAddr(container))
+	require.Contains(t, container.Args, "0.0.0.0:9000")
+	require.Equal(t, intstr.FromInt(9000), container.ReadinessProbe.HTTPGet.Port)
+
+	// The port of the app takes precedence
+	app.Spec.ListenPort = 8080
+	deployment, err = constructDeployment(context.Background(), app, spintainerConfig, "", "", nil)
+	require.NoError(t, err)
+	container = deployment.Spec.Template.Spec.Containers[0]
+	require.Equal(t, int32(8080), container.Ports[0].ContainerPort)
+	require.Equal(t, "0.0.0.0:8080", listenAddr(container))
+	require.Contains(t, container.Args, "0.0.0.0:8080")
+	require.Equal(t, intstr.FromInt(8080), container.ReadinessProbe.HTTPGet.Port)
+
+	// The service keeps serving the app on port 80 through the named port
+	svc := constructService(app)
+	require.Equal(t, int32(80), svc.Spec.Ports[0].Port)
+	require.Equal(t, intstr.FromString("http-app"), svc.Spec.Ports[0].TargetPort)
+}
+
 func TestReconcile_Integration_AnnotationAndLabelPropagation(t *testing.T) {
 	t.Parallel()
 
@@ -519,6 +817,93 @@ func TestReconcile_Integration_AnnotationAndLabelPropagation(t *testing.T) {
 	wg.Wait()
 }
 
+func TestReconcile_Integration_StatusWithConcurrentSpecEdits(t *testing.T) {
+	t.Parallel()
+
+	envTest, mgr, _ := setupController(t)
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancelFunc()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		require.NoError(t, mgr.Start(ctx))
+		wg.Done()
+	}()
+
+	executor := &spinv1alpha1.SpinAppExecutor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "executor",
+			Namespace: "default",
+		},
+		Spec: spinv1alpha1.SpinAppExecutorSpec{
+			CreateDeployment: true,
+			DeploymentConfig: &spinv1alpha1.ExecutorDeploymentConfig{
+				RuntimeClassName: generics.Ptr("a-runtime-class"),
+			},
+		},
+	}
+	require.NoError(t, envTest.k8sClient.Create(ctx, executor))
+
+	spinApp := &spinv1alpha1.SpinApp{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+		},
+		Spec: spinv1alpha1.SpinAppSpec{
+			Executor: "executor",
+			Image:    "ghcr.io/radu-matei/perftest:v1",
+			Replicas: 1,
+		},
+	}
+	require.NoError(t, envTest.k8sClient.Create(ctx, spinApp))
+
+	// Edit the spec from several clients while the operator writes the status
+	const edits = 10
+	var editors sync.WaitGroup
+	for i := range edits {
+		editors.Add(1)
+		go func() {
+			defer editors.Done()
+			err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+				var app spinv1alpha1.SpinApp
+				if err := envTest.k8sClient.Get(ctx, client.ObjectKeyFromObject(spinApp), &app); err != nil {
+					return err
+				}
+				if app.Spec.PodAnnotations == nil {
+					app.Spec.PodAnnotations = map[string]string{}
+				}
+				app.Spec.PodAnnotations[fmt.Sprintf("edit-%d", i)] = "true"
+				return envTest.k8sClient.Update(ctx, &app)
+			})
+			require.NoError(t, err)
+		}()
+	}
+	editors.Wait()
+
+	// The status catches up with the latest spec without losing any edits
+	var app spinv1alpha1.SpinApp
+	require.Eventually(t, func() bool {
+		if err := envTest.k8sClient.Get(ctx, client.ObjectKeyFromObject(spinApp), &app); err != nil {
+			return false
+		}
+		return app.Status.ObservedGeneration == app.Generation
+	}, 10*time.Second, 100*time.Millisecond)
+	require.Len(t, app.Spec.PodAnnotations, edits)
+	require.NotNil(t, meta.FindStatusCondition(app.Status.Conditions, spinAppConditionReady))
+
+	// The status is owned by the operator through server-side apply
+	require.True(t, slices.ContainsFunc(app.ManagedFields, func(entry metav1.ManagedFieldsEntry) bool {
+		return entry.Manager == FieldManager && entry.Operation == metav1.ManagedFieldsOperationApply &&
+			entry.Subresource == "status"
+	}))
+
+	// Terminate the context to force the manager to shut down.
+	cancelFunc()
+	wg.Wait()
+}
+
 func TestReconcile_Integration_Deployment_SpinCAInjection(t *testing.T) {
 	t.Parallel()
 
@@ -630,3 +1015,90 @@ func TestReconcile_Integration_Deployment_SpinCAInjection(t *testing.T) {
 	cancelFunc()
 	wg.Wait()
 }
+
+func TestPruneRuntimeConfigSecrets(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientscheme.AddToScheme(scheme))
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	app := minimalSpinApp()
+	app.UID = "my-app-uid"
+
+	runtimeConfigSecret := func(name string) *corev1.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: app.Namespace,
+				Labels:    map[string]string{spinapp.NameLabelKey: app.Name},
+			},
+		}
+		require.NoError(t, controllerutil.SetOwnerReference(app, secret, scheme))
+		return secret
+	}
+
+	replicaSet := func(name, secretName string, replicas int32, age time.Duration) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         app.Namespace,
+				Labels:            map[string]string{spinapp.NameLabelKey: app.Name},
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			},
+			Spec: appsv1.ReplicaSetSpec{
+				Replicas: generics.Ptr(replicas),
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Volumes: []corev1.Volume{{
+							Name: runtimeConfigVolumeName,
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{SecretName: secretName},
+							},
+						}},
+					},
+				},
+			},
+		}
+	}
+
+	// A secret that happens to carry the app label but wasn't generated by the operator.
+	foreignSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-app-user-provided",
+			Namespace: app.Namespace,
+			Labels:    map[string]string{spinapp.NameLabelKey: app.Name},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		runtimeConfigSecret("my-app-current"),
+		runtimeConfigSecret("my-app-running"),
+		runtimeConfigSecret("my-app-recent"),
+		runtimeConfigSecret("my-app-old"),
+		runtimeConfigSecret("my-app-unreferenced"),
+		foreignSecret,
+		replicaSet("my-app-1", "my-app-old", 0, 4*time.Hour),
+		replicaSet("my-app-2", "my-app-recent", 0, 3*time.Hour),
+		replicaSet("my-app-3", "my-app-running", 1, 2*time.Hour),
+		replicaSet("my-app-4", "my-app-current", 1, time.Hour),
+	).Build()
+
+	r := &SpinAppReconciler{
+		Client:                    c,
+		Scheme:                    scheme,
+		RuntimeConfigHistoryLimit: 1,
+	}
+
+	require.NoError(t, r.pruneRuntimeConfigSecrets(context.Background(), app, "my-app-current"))
+
+	var secrets corev1.SecretList
+	require.NoError(t, c.List(context.Background(), &secrets))
+	names := generics.MapList(secrets.Items, func(s corev1.Secret) string { return s.Name })
+	require.ElementsMatch(t, []string{
+		"my-app-current",
+		"my-app-running",
+		"my-app-recent",
+		"my-app-user-provided",
+	}, names)
+}
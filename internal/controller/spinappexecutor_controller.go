@@ -20,11 +20,20 @@ import (
 	"context"
 	"errors"
 
+	corev1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
 	"github.com/spinkube/spin-operator/internal/logging"
@@ -45,6 +54,8 @@ type SpinAppExecutorReconciler struct {
 //+kubebuilder:rbac:groups=core.spinkube.dev,resources=spinappexecutors/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=core.spinkube.dev,resources=spinappexecutors/finalizers,verbs=update
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=node.k8s.io,resources=runtimeclasses,verbs=get;list;watch
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *SpinAppExecutorReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -59,9 +70,69 @@ func (r *SpinAppExecutorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&spinv1alpha1.SpinAppExecutor{}).
+		// Keep the app count up to date as apps are created, deleted, or
+		// switch executors.
+		Watches(&spinv1alpha1.SpinApp{},
+			handler.EnqueueRequestsFromMapFunc(r.findExecutorForSpinApp),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(&nodev1.RuntimeClass{}, handler.EnqueueRequestsFromMapFunc(r.findExecutorsForRuntimeClass)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.findExecutorsForSecret)).
 		Complete(r)
 }
 
+// findExecutorForSpinApp returns a reconcile request for the SpinAppExecutor
+// referenced by a SpinApp.
+func (r *SpinAppExecutorReconciler) findExecutorForSpinApp(ctx context.Context, obj client.Object) []reconcile.Request {
+	app := obj.(*spinv1alpha1.SpinApp)
+	if app.Spec.Executor == "" {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: app.Namespace, Name: app.Spec.Executor}}}
+}
+
+// findExecutorsForRuntimeClass returns a reconcile request for every
+// SpinAppExecutor that uses the given RuntimeClass.
+func (r *SpinAppExecutorReconciler) findExecutorsForRuntimeClass(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.findExecutorsMatching(ctx, "", func(config *spinv1alpha1.ExecutorDeploymentConfig) bool {
+		return config.RuntimeClassName != nil && *config.RuntimeClassName == obj.GetName()
+	})
+}
+
+// findExecutorsForSecret returns a reconcile request for every SpinAppExecutor
+// in the namespace of the given Secret that uses it as its CA certificate
+// secret.
+func (r *SpinAppExecutorReconciler) findExecutorsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.findExecutorsMatching(ctx, obj.GetNamespace(), func(config *spinv1alpha1.ExecutorDeploymentConfig) bool {
+		return config.CACertSecret == obj.GetName()
+	})
+}
+
+// findExecutorsMatching returns a reconcile request for every SpinAppExecutor
+// in the namespace (or in all namespaces when empty) whose deployment config
+// matches.
+func (r *SpinAppExecutorReconciler) findExecutorsMatching(ctx context.Context, namespace string, matches func(*spinv1alpha1.ExecutorDeploymentConfig) bool) []reconcile.Request {
+	log := logging.FromContext(ctx)
+
+	var executors spinv1alpha1.SpinAppExecutorList
+	if err := r.Client.List(ctx, &executors, client.InNamespace(namespace)); err != nil {
+		log.Error(err, "Unable to list SpinAppExecutors")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, executor := range executors.Items {
+		if executor.Spec.DeploymentConfig != nil && matches(executor.Spec.DeploymentConfig) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: executor.Namespace,
+				Name:      executor.Name,
+			}})
+		}
+	}
+	return requests
+}
+
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 //
@@ -93,22 +164,67 @@ func (r *SpinAppExecutorReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	}
 
 	// Make sure the finalizer is present
-	err := r.ensureFinalizer(ctx, &executor)
+	if err := r.ensureFinalizer(ctx, &executor); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	err := r.updateStatus(ctx, &executor)
+	if err != nil {
+		log.Error(err, "Unable to update status")
+	}
 	return ctrl.Result{}, client.IgnoreNotFound(err)
 }
 
+// updateStatus records the number of SpinApps using the SpinAppExecutor and
+// the health of the resources it depends on.
+func (r *SpinAppExecutorReconciler) updateStatus(ctx context.Context, executor *spinv1alpha1.SpinAppExecutor) error {
+	var spinApps spinv1alpha1.SpinAppList
+	if err := r.Client.List(ctx, &spinApps,
+		client.InNamespace(executor.Namespace),
+		client.MatchingFields{spinAppExecutorKey: executor.Name}); err != nil {
+		return err
+	}
+
+	status := executor.Status.DeepCopy()
+	if err := observeExecutorStatus(ctx, r.Client, status, &executor.Spec, executor.Generation,
+		len(spinApps.Items), []string{executor.Namespace}); err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(status, &executor.Status) {
+		return nil
+	}
+
+	executor.Status = *status
+	return r.Client.Status().Update(ctx, executor)
+}
+
 // handleDeletion makes sure no SpinApps are dependent on the SpinAppExecutor
 // before allowing it to be deleted.
 func (r *SpinAppExecutorReconciler) handleDeletion(ctx context.Context, executor *spinv1alpha1.SpinAppExecutor) error {
 	log := logging.FromContext(ctx)
 
 	var spinApps spinv1alpha1.SpinAppList
-	if err := r.Client.List(ctx, &spinApps, client.MatchingFields{spinAppExecutorKey: executor.Name}); err != nil {
+	if err := r.Client.List(ctx, &spinApps,
+		client.InNamespace(executor.Namespace),
+		client.MatchingFields{spinAppExecutorKey: executor.Name}); err != nil {
 		log.Error(err, "Unable to list SpinApps")
 		return err
 	}
 
-	if len(spinApps.Items) > 0 {
+	if len(spinApps.Items) == 0 {
+		return nil
+	}
+
+	// Apps fall back to a ClusterSpinAppExecutor with the same name, so they
+	// aren't left without an executor.
+	err := r.Client.Get(ctx, types.NamespacedName{Name: executor.Name}, &spinv1alpha1.ClusterSpinAppExecutor{})
+	if client.IgnoreNotFound(err) != nil {
+		log.Error(err, "Unable to fetch ClusterSpinAppExecutor")
+		return err
+	}
+
+	if apierrors.IsNotFound(err) {
 		r.Recorder.Event(executor, "Warning", "DeletionBlocked", "Cannot delete SpinAppExecutor with dependent SpinApps")
 		return errors.New("cannot delete SpinAppExecutor with dependent SpinApps")
 	}
@@ -0,0 +1,407 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/runtimeconfig"
+)
+
+// spinAppConditionReady summarizes whether the runtime config of a SpinApp was
+// rendered, its executor was resolved and its Deployment is healthy and up to
+// date.
+const spinAppConditionReady = "Ready"
+
+// spinAppConditionExecutorResolved reports whether the SpinAppExecutor or
+// ClusterSpinAppExecutor of a SpinApp was found.
+const spinAppConditionExecutorResolved = "ExecutorResolved"
+
+// spinAppConditionRuntimeConfigReady reports whether the runtime config of a
+// SpinApp was rendered, naming the missing object or key when it wasn't.
+const spinAppConditionRuntimeConfigReady = "RuntimeConfigReady"
+
+// errRuntimeConfigNotRendered is returned when the runtime config of a SpinApp
+// can't be rendered.
+var errRuntimeConfigNotRendered = errors.New("failed to construct RuntimeConfig")
+
+// statusObservation is what the operator observed about a SpinApp and its
+// child resources while reconciling it.
+type statusObservation struct {
+	// Executor is nil when the executor of the app couldn't be resolved.
+	Executor *spinv1alpha1.SpinAppExecutor
+
+	// Deployment is nil when it doesn't exist.
+	Deployment *appsv1.Deployment
+	Pods       []corev1.Pod
+
+	// RuntimeConfigRendered is set once the runtime config was rendered, and
+	// RuntimeConfigError when rendering it failed. Neither is set when it wasn't
+	// attempted.
+	RuntimeConfigRendered   bool
+	RuntimeConfigError      error
+	RuntimeConfigSecretName string
+	CurrentRevision         int64
+
+	// KEDAInstalled records whether KEDA was installed when the ScaledObject
+	// of an app that uses KEDA autoscaling was reconciled. It is nil when that
+	// didn't happen.
+	KEDAInstalled *bool
+
+	// GatewayAPIInstalled records whether the Gateway API was installed when
+	// the HTTPRoute of an app exposed through one was reconciled. It is nil
+	// when that didn't happen.
+	GatewayAPIInstalled *bool
+
+	// FailureReason and FailureMessage describe the error that stopped the
+	// reconcile, if any.
+	FailureReason  string
+	FailureMessage string
+}
+
+// observeDeployment records the Deployment of a SpinApp and its pods.
+func (r *SpinAppReconciler) observeDeployment(ctx context.Context, app *spinv1alpha1.SpinApp, observed *statusObservation) error {
+	deployment, err := r.findDeploymentForApp(ctx, app)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	if err != nil {
+		// A Deployment that was just created may not be in the cache yet
+		return nil
+	}
+	observed.Deployment = deployment
+
+	observed.Pods, err = r.listPodsForApp(ctx, app)
+	return err
+}
+
+// computeStatus derives the status of a SpinApp from what was observed while
+// reconciling it. The progressive rollout has already been advanced on the
+// status of the app.
+func computeStatus(app *spinv1alpha1.SpinApp, observed *statusObservation) spinv1alpha1.SpinAppStatus {
+	status := *app.Status.DeepCopy()
+	status.ActiveScheduler = app.Spec.Executor
+	status.Selector = labels.SelectorFromSet(constructPodSelectorLabels(app)).String()
+	status.URL = exposureURL(app)
+	status.Image = resolvedImage(app)
+
+	// The generated runtime config and the current revision are only known
+	// once the child resources have been reconciled.
+	if observed.FailureReason == "" {
+		status.RuntimeConfigSecretName = observed.RuntimeConfigSecretName
+		status.CurrentRevision = observed.CurrentRevision
+	}
+
+	deployment := observed.Deployment
+	status.Replicas, status.ReadyReplicas, status.UpdatedReplicas = 0, 0, 0
+	switch {
+	case observed.Executor != nil && !observed.Executor.Spec.CreateDeployment:
+		for _, conditionType := range []string{"Available", "Progressing", spinAppConditionRolloutFailed, spinAppConditionDegraded} {
+			meta.RemoveStatusCondition(&status.Conditions, conditionType)
+		}
+	case deployment == nil:
+		// Deployment doesn't exist yet so set conditions as unknown
+		for _, conditionType := range []string{"Available", "Progressing"} {
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+				Type:    conditionType,
+				Status:  metav1.ConditionUnknown,
+				Reason:  "DeploymentNotFound",
+				Message: "Deployment not found",
+			})
+		}
+		meta.RemoveStatusCondition(&status.Conditions, spinAppConditionRolloutFailed)
+		meta.RemoveStatusCondition(&status.Conditions, spinAppConditionDegraded)
+	default:
+		for _, dc := range deployment.Status.Conditions {
+			if dc.Type == appsv1.DeploymentAvailable || dc.Type == appsv1.DeploymentProgressing {
+				meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+					Type:    string(dc.Type),
+					Status:  metav1.ConditionStatus(dc.Status),
+					Reason:  dc.Reason,
+					Message: dc.Message,
+				})
+			}
+		}
+		status.Replicas = deployment.Status.Replicas
+		status.ReadyReplicas = deployment.Status.ReadyReplicas
+		status.UpdatedReplicas = deployment.Status.UpdatedReplicas
+		meta.SetStatusCondition(&status.Conditions, rolloutFailedCondition(deployment))
+		meta.SetStatusCondition(&status.Conditions, degradedCondition(deployment, observed.Pods))
+	}
+
+	meta.SetStatusCondition(&status.Conditions, executorResolvedCondition(observed))
+	switch {
+	case observed.Executor != nil && !observed.Executor.Spec.CreateDeployment:
+		meta.RemoveStatusCondition(&status.Conditions, spinAppConditionRuntimeConfigReady)
+	case observed.RuntimeConfigRendered || observed.RuntimeConfigError != nil:
+		meta.SetStatusCondition(&status.Conditions, runtimeConfigReadyCondition(app, observed))
+	}
+
+	meta.SetStatusCondition(&status.Conditions, suspendedCondition(app))
+
+	switch {
+	case observed.Executor != nil && !usesKEDAAutoscaling(app, observed.Executor):
+		meta.RemoveStatusCondition(&status.Conditions, spinAppConditionScaledObjectReady)
+	case observed.KEDAInstalled != nil:
+		meta.SetStatusCondition(&status.Conditions, scaledObjectReadyCondition(*observed.KEDAInstalled))
+	}
+
+	switch {
+	case !exposedThroughHTTPRoute(app):
+		meta.RemoveStatusCondition(&status.Conditions, spinAppConditionHTTPRouteReady)
+	case observed.GatewayAPIInstalled != nil:
+		meta.SetStatusCondition(&status.Conditions, httpRouteReadyCondition(*observed.GatewayAPIInstalled))
+	}
+
+	if observed.FailureReason != "" {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:    spinAppConditionReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  observed.FailureReason,
+			Message: observed.FailureMessage,
+		})
+	} else {
+		meta.SetStatusCondition(&status.Conditions, readyCondition(app, &status, observed.Executor, deployment))
+	}
+
+	status.ObservedGeneration = app.Generation
+	for idx := range status.Conditions {
+		status.Conditions[idx].ObservedGeneration = app.Generation
+	}
+
+	return status
+}
+
+// executorResolvedCondition derives the ExecutorResolved condition of a
+// SpinApp. The executor is resolved first, so a failure to do so is the
+// failure of the reconcile.
+func executorResolvedCondition(observed *statusObservation) metav1.Condition {
+	executor := observed.Executor
+	if executor == nil {
+		return metav1.Condition{
+			Type:    spinAppConditionExecutorResolved,
+			Status:  metav1.ConditionFalse,
+			Reason:  observed.FailureReason,
+			Message: observed.FailureMessage,
+		}
+	}
+
+	// Cluster executors are resolved without a namespace
+	message := fmt.Sprintf("Using ClusterSpinAppExecutor %s", executor.Name)
+	if executor.Namespace != "" {
+		message = fmt.Sprintf("Using SpinAppExecutor %s/%s", executor.Namespace, executor.Name)
+	}
+	return metav1.Condition{
+		Type:    spinAppConditionExecutorResolved,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ExecutorFound",
+		Message: message,
+	}
+}
+
+// runtimeConfigFailure returns the reason and message describing why the
+// runtime config of a SpinApp couldn't be rendered.
+func runtimeConfigFailure(err error) (string, string) {
+	var rcErr *runtimeconfig.Error
+	if errors.As(err, &rcErr) {
+		return rcErr.Reason, rcErr.Message
+	}
+	return "RuntimeConfigNotRendered", err.Error()
+}
+
+// runtimeConfigReadyCondition derives the RuntimeConfigReady condition of a
+// SpinApp whose runtime config was rendered or failed to render.
+func runtimeConfigReadyCondition(app *spinv1alpha1.SpinApp, observed *statusObservation) metav1.Condition {
+	if observed.RuntimeConfigError != nil {
+		reason, message := runtimeConfigFailure(observed.RuntimeConfigError)
+		return metav1.Condition{
+			Type:    spinAppConditionRuntimeConfigReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: message,
+		}
+	}
+
+	message := fmt.Sprintf("Rendered the runtime config into Secret %s", observed.RuntimeConfigSecretName)
+	if app.Spec.RuntimeConfig.LoadFromSecret != "" {
+		message = fmt.Sprintf("Loading the runtime config from Secret %s", app.Spec.RuntimeConfig.LoadFromSecret)
+	}
+	return metav1.Condition{
+		Type:    spinAppConditionRuntimeConfigReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  "RuntimeConfigRendered",
+		Message: message,
+	}
+}
+
+// resolvedImage returns the app image the stable Deployment of a SpinApp runs.
+func resolvedImage(app *spinv1alpha1.SpinApp) string {
+	if app.Status.Rollout != nil && app.Status.Rollout.StableImage != "" {
+		return app.Status.Rollout.StableImage
+	}
+	return app.Spec.Image
+}
+
+// readyCondition derives the Ready condition of a SpinApp whose child resources
+// have been reconciled from its status and its Deployment, which is nil when
+// it doesn't exist.
+func readyCondition(app *spinv1alpha1.SpinApp, status *spinv1alpha1.SpinAppStatus,
+	executor *spinv1alpha1.SpinAppExecutor, deployment *appsv1.Deployment) metav1.Condition {
+	condition := metav1.Condition{Type: spinAppConditionReady, Status: metav1.ConditionFalse}
+
+	switch {
+	case !executor.Spec.CreateDeployment:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "DeploymentNotManaged"
+		condition.Message = "The executor does not use operator deployments"
+	case deployment == nil:
+		condition.Reason = "DeploymentNotFound"
+		condition.Message = "Waiting for the Deployment to be created"
+	case meta.IsStatusConditionTrue(status.Conditions, spinAppConditionDegraded):
+		degraded := meta.FindStatusCondition(status.Conditions, spinAppConditionDegraded)
+		condition.Reason = degraded.Reason
+		condition.Message = degraded.Message
+	case meta.IsStatusConditionTrue(status.Conditions, spinAppConditionRolloutFailed):
+		condition.Reason = "RolloutFailed"
+		condition.Message = meta.FindStatusCondition(status.Conditions, spinAppConditionRolloutFailed).Message
+	case !deploymentUpToDate(deployment):
+		condition.Reason = "RolloutInProgress"
+		condition.Message = "Waiting for the Deployment to roll out"
+	case app.Spec.Suspend:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Suspended"
+		condition.Message = "The app is suspended and scaled to zero"
+	case !deploymentAvailable(deployment):
+		condition.Reason = "DeploymentUnavailable"
+		condition.Message = "The Deployment does not have minimum availability"
+	default:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "DeploymentAvailable"
+		condition.Message = "The app is available and up to date"
+	}
+
+	return condition
+}
+
+// deploymentUpToDate returns whether all replicas of a Deployment run its
+// latest pod template.
+func deploymentUpToDate(deployment *appsv1.Deployment) bool {
+	status := deployment.Status
+	if status.ObservedGeneration < deployment.Generation {
+		return false
+	}
+	if deployment.Spec.Replicas != nil && status.UpdatedReplicas < *deployment.Spec.Replicas {
+		return false
+	}
+	return status.Replicas == status.UpdatedReplicas
+}
+
+// deploymentAvailable returns whether a Deployment has minimum availability.
+func deploymentAvailable(deployment *appsv1.Deployment) bool {
+	for _, dc := range deployment.Status.Conditions {
+		if dc.Type == appsv1.DeploymentAvailable {
+			return dc.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// recordStatusEvents emits events for the condition transitions between the
+// previous and current status of a SpinApp.
+func (r *SpinAppReconciler) recordStatusEvents(app *spinv1alpha1.SpinApp, previous, current *spinv1alpha1.SpinAppStatus) {
+	transitioned := func(conditionType string) (*metav1.Condition, bool) {
+		before := meta.FindStatusCondition(previous.Conditions, conditionType)
+		after := meta.FindStatusCondition(current.Conditions, conditionType)
+		if after == nil {
+			return nil, false
+		}
+		return after, before == nil || before.Status != after.Status || before.Reason != after.Reason
+	}
+
+	if condition, ok := transitioned(spinAppConditionRolloutFailed); ok && condition.Status == metav1.ConditionTrue {
+		r.Recorder.Event(app, "Warning", "RolloutFailed", condition.Message)
+	}
+	if condition, ok := transitioned(spinAppConditionDegraded); ok && condition.Status == metav1.ConditionTrue {
+		r.Recorder.Event(app, "Warning", condition.Reason, condition.Message)
+	}
+	if condition, ok := transitioned(spinAppConditionScaledObjectReady); ok && condition.Status == metav1.ConditionFalse {
+		r.Recorder.Event(app, "Warning", condition.Reason, condition.Message)
+	}
+	if condition, ok := transitioned(spinAppConditionHTTPRouteReady); ok && condition.Status == metav1.ConditionFalse {
+		r.Recorder.Event(app, "Warning", condition.Reason, condition.Message)
+	}
+	// Apps that were never suspended aren't reported as resumed
+	if condition, ok := transitioned(spinAppConditionSuspended); ok &&
+		meta.FindStatusCondition(previous.Conditions, spinAppConditionSuspended) != nil {
+		if condition.Status == metav1.ConditionTrue {
+			r.Recorder.Event(app, "Normal", "Suspended", "Scaled the app to zero")
+		} else {
+			r.Recorder.Event(app, "Normal", "Resumed", "Restored the replicas of the app")
+		}
+	}
+}
+
+// applyStatus computes the status of a SpinApp and applies it to the status
+// subresource with server-side apply. The operator owns the whole status, so
+// there's no need to read the latest version of the app before writing it.
+func (r *SpinAppReconciler) applyStatus(ctx context.Context, app *spinv1alpha1.SpinApp,
+	previous *spinv1alpha1.SpinAppStatus, observed *statusObservation) error {
+	if observed.Executor != nil && observed.Executor.Spec.CreateDeployment {
+		if err := r.observeDeployment(ctx, app, observed); err != nil {
+			return fmt.Errorf("failed to observe Deployment: %w", err)
+		}
+	}
+
+	status := computeStatus(app, observed)
+	r.recordStatusEvents(app, previous, &status)
+	app.Status = status
+
+	patch, err := constructStatusPatch(app)
+	if err != nil {
+		return err
+	}
+
+	return r.Client.Status().Patch(ctx, patch, client.Apply, client.ForceOwnership, client.FieldOwner(FieldManager))
+}
+
+// constructStatusPatch builds the server-side apply patch for the status of a
+// SpinApp. It only contains the status, so that the operator doesn't take
+// ownership of any fields of the spec.
+func constructStatusPatch(app *spinv1alpha1.SpinApp) (*unstructured.Unstructured, error) {
+	status, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&app.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert status: %w", err)
+	}
+
+	patch := &unstructured.Unstructured{Object: map[string]interface{}{"status": status}}
+	patch.SetGroupVersionKind(spinv1alpha1.GroupVersion.WithKind("SpinApp"))
+	patch.SetName(app.Name)
+	patch.SetNamespace(app.Namespace)
+	return patch, nil
+}
@@ -0,0 +1,279 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/generics"
+	"github.com/spinkube/spin-operator/internal/runtimeconfig"
+)
+
+func availableDeployment(app *spinv1alpha1.SpinApp) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: app.Name, Namespace: app.Namespace, Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Replicas: generics.Ptr(app.Spec.Replicas)},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           app.Spec.Replicas,
+			UpdatedReplicas:    app.Spec.Replicas,
+			ReadyReplicas:      app.Spec.Replicas,
+			Conditions: []appsv1.DeploymentCondition{{
+				Type:   appsv1.DeploymentAvailable,
+				Status: corev1.ConditionTrue,
+			}},
+		},
+	}
+}
+
+func TestReadyCondition(t *testing.T) {
+	t.Parallel()
+
+	executor := &spinv1alpha1.SpinAppExecutor{Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true}}
+	app := minimalSpinApp()
+	app.Spec.Replicas = 2
+	status := &spinv1alpha1.SpinAppStatus{}
+
+	deployment := availableDeployment(app)
+	condition := readyCondition(app, status, executor, deployment)
+	require.Equal(t, metav1.ConditionTrue, condition.Status)
+	require.Equal(t, "DeploymentAvailable", condition.Reason)
+
+	require.Equal(t, "DeploymentNotFound", readyCondition(app, status, executor, nil).Reason)
+
+	// The Deployment controller hasn't observed the latest spec yet
+	deployment.Generation = 3
+	condition = readyCondition(app, status, executor, deployment)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, "RolloutInProgress", condition.Reason)
+
+	// Old replicas are still running
+	deployment = availableDeployment(app)
+	deployment.Status.Replicas = 3
+	require.Equal(t, "RolloutInProgress", readyCondition(app, status, executor, deployment).Reason)
+
+	deployment = availableDeployment(app)
+	deployment.Status.Conditions[0].Status = corev1.ConditionFalse
+	require.Equal(t, "DeploymentUnavailable", readyCondition(app, status, executor, deployment).Reason)
+
+	// Pod failures take precedence
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:    spinAppConditionDegraded,
+		Status:  metav1.ConditionTrue,
+		Reason:  "CrashLoopBackOff",
+		Message: "back-off 10s restarting failed container",
+	})
+	condition = readyCondition(app, status, executor, deployment)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, "CrashLoopBackOff", condition.Reason)
+	require.Equal(t, "back-off 10s restarting failed container", condition.Message)
+
+	// The operator can't tell the health of apps it doesn't deploy
+	condition = readyCondition(app, status, &spinv1alpha1.SpinAppExecutor{}, nil)
+	require.Equal(t, metav1.ConditionTrue, condition.Status)
+	require.Equal(t, "DeploymentNotManaged", condition.Reason)
+}
+
+func TestComputeStatus(t *testing.T) {
+	t.Parallel()
+
+	executor := &spinv1alpha1.SpinAppExecutor{Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true}}
+	app := minimalSpinApp()
+	app.Generation = 4
+	app.Status.Rollout = &spinv1alpha1.RolloutStatus{StableImage: "fakereg.dev/noapp:v1"}
+
+	status := computeStatus(app, &statusObservation{
+		Executor:                executor,
+		Deployment:              availableDeployment(app),
+		RuntimeConfigSecretName: "my-app-1a2b3c",
+		CurrentRevision:         2,
+	})
+	require.Equal(t, int64(4), status.ObservedGeneration)
+	require.Equal(t, int32(1), status.Replicas)
+	require.Equal(t, int32(1), status.ReadyReplicas)
+	require.Equal(t, int32(1), status.UpdatedReplicas)
+	require.Equal(t, "fakereg.dev/noapp:v1", status.Image)
+	require.Equal(t, "my-app-1a2b3c", status.RuntimeConfigSecretName)
+	require.Equal(t, int64(2), status.CurrentRevision)
+	require.Equal(t, "containerd-shim-spin", status.ActiveScheduler)
+	require.True(t, meta.IsStatusConditionTrue(status.Conditions, spinAppConditionReady))
+	require.True(t, meta.IsStatusConditionTrue(status.Conditions, "Available"))
+	require.True(t, meta.IsStatusConditionFalse(status.Conditions, spinAppConditionDegraded))
+	require.True(t, meta.IsStatusConditionFalse(status.Conditions, spinAppConditionSuspended))
+	for _, condition := range status.Conditions {
+		require.Equal(t, int64(4), condition.ObservedGeneration, condition.Type)
+	}
+	// The app itself isn't modified
+	require.Empty(t, app.Status.Conditions)
+
+	// Failures keep what is only known after a successful reconcile
+	app.Status = status
+	app.Generation = 5
+	status = computeStatus(app, &statusObservation{
+		Executor:       executor,
+		Deployment:     availableDeployment(app),
+		FailureReason:  "RuntimeConfigNotRendered",
+		FailureMessage: "failed to construct RuntimeConfig",
+	})
+	require.Equal(t, int64(5), status.ObservedGeneration)
+	require.Equal(t, "my-app-1a2b3c", status.RuntimeConfigSecretName)
+	require.Equal(t, int64(2), status.CurrentRevision)
+	ready := meta.FindStatusCondition(status.Conditions, spinAppConditionReady)
+	require.Equal(t, metav1.ConditionFalse, ready.Status)
+	require.Equal(t, "RuntimeConfigNotRendered", ready.Reason)
+
+	// Deployment conditions are dropped when the executor doesn't use operator
+	// deployments
+	status = computeStatus(app, &statusObservation{Executor: &spinv1alpha1.SpinAppExecutor{}})
+	require.Nil(t, meta.FindStatusCondition(status.Conditions, "Available"))
+	require.Nil(t, meta.FindStatusCondition(status.Conditions, spinAppConditionRuntimeConfigReady))
+	require.Nil(t, meta.FindStatusCondition(status.Conditions, spinAppConditionDegraded))
+	require.Equal(t, int32(0), status.Replicas)
+	require.True(t, meta.IsStatusConditionTrue(status.Conditions, spinAppConditionReady))
+}
+
+func TestComputeStatus_ReconcileFailures(t *testing.T) {
+	t.Parallel()
+
+	app := minimalSpinApp()
+	executor := &spinv1alpha1.SpinAppExecutor{
+		ObjectMeta: metav1.ObjectMeta{Name: "containerd-shim-spin", Namespace: "default"},
+		Spec:       spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true},
+	}
+
+	status := computeStatus(app, &statusObservation{
+		Executor:                executor,
+		RuntimeConfigRendered:   true,
+		RuntimeConfigSecretName: "my-app-1a2b3c",
+	})
+	resolved := meta.FindStatusCondition(status.Conditions, spinAppConditionExecutorResolved)
+	require.Equal(t, metav1.ConditionTrue, resolved.Status)
+	require.Equal(t, "Using SpinAppExecutor default/containerd-shim-spin", resolved.Message)
+	rcReady := meta.FindStatusCondition(status.Conditions, spinAppConditionRuntimeConfigReady)
+	require.Equal(t, metav1.ConditionTrue, rcReady.Status)
+	require.Equal(t, "Rendered the runtime config into Secret my-app-1a2b3c", rcReady.Message)
+
+	// Missing runtime config dependencies are named in the condition
+	app.Status = status
+	err := fmt.Errorf("%w: %w", errRuntimeConfigNotRendered, &runtimeconfig.Error{
+		Reason:  runtimeconfig.ReasonSecretNotFound,
+		Message: "secret default/redis not found",
+	})
+	reason, message := runtimeConfigFailure(err)
+	status = computeStatus(app, &statusObservation{
+		Executor:           executor,
+		RuntimeConfigError: err,
+		FailureReason:      reason,
+		FailureMessage:     message,
+	})
+	rcReady = meta.FindStatusCondition(status.Conditions, spinAppConditionRuntimeConfigReady)
+	require.Equal(t, metav1.ConditionFalse, rcReady.Status)
+	require.Equal(t, runtimeconfig.ReasonSecretNotFound, rcReady.Reason)
+	require.Equal(t, "secret default/redis not found", rcReady.Message)
+	ready := meta.FindStatusCondition(status.Conditions, spinAppConditionReady)
+	require.Equal(t, runtimeconfig.ReasonSecretNotFound, ready.Reason)
+
+	// The runtime config condition is kept as is when the executor can't be
+	// resolved, as the runtime config isn't rendered
+	app.Status = status
+	status = computeStatus(app, &statusObservation{
+		FailureReason:  "ExecutorNotFound",
+		FailureMessage: "Could not find SpinAppExecutor default/containerd-shim-spin",
+	})
+	resolved = meta.FindStatusCondition(status.Conditions, spinAppConditionExecutorResolved)
+	require.Equal(t, metav1.ConditionFalse, resolved.Status)
+	require.Equal(t, "ExecutorNotFound", resolved.Reason)
+	require.Equal(t, runtimeconfig.ReasonSecretNotFound,
+		meta.FindStatusCondition(status.Conditions, spinAppConditionRuntimeConfigReady).Reason)
+
+	// Cluster executors have no namespace
+	require.Equal(t, "Using ClusterSpinAppExecutor containerd-shim-spin", executorResolvedCondition(&statusObservation{
+		Executor: &spinv1alpha1.SpinAppExecutor{ObjectMeta: metav1.ObjectMeta{Name: "containerd-shim-spin"}},
+	}).Message)
+
+	// Other errors have a generic reason
+	reason, _ = runtimeConfigFailure(fmt.Errorf("%w: boom", errRuntimeConfigNotRendered))
+	require.Equal(t, "RuntimeConfigNotRendered", reason)
+}
+
+func TestApplyStatus(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	app := minimalSpinApp()
+	app.Generation = 2
+	app.ResourceVersion = "42"
+	app.Spec.Suspend = true
+
+	// The fake client doesn't support server-side apply, so the patch is
+	// captured instead.
+	var patches []client.Object
+	var patchOptions []client.SubResourcePatchOption
+	recorder := record.NewFakeRecorder(2)
+	r := &SpinAppReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(availableDeployment(app)).
+			WithInterceptorFuncs(interceptor.Funcs{
+				SubResourcePatch: func(_ context.Context, _ client.Client, subResource string, obj client.Object,
+					patch client.Patch, opts ...client.SubResourcePatchOption) error {
+					require.Equal(t, "status", subResource)
+					require.Equal(t, types.ApplyPatchType, patch.Type())
+					patches = append(patches, obj)
+					patchOptions = opts
+					return nil
+				},
+			}).Build(),
+		Scheme:   scheme,
+		Recorder: recorder,
+	}
+
+	previous := &spinv1alpha1.SpinAppStatus{Conditions: []metav1.Condition{{
+		Type:   spinAppConditionSuspended,
+		Status: metav1.ConditionFalse,
+		Reason: "NotSuspended",
+	}}}
+	executor := &spinv1alpha1.SpinAppExecutor{Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true}}
+
+	ctx := context.Background()
+	require.NoError(t, r.applyStatus(ctx, app, previous, &statusObservation{Executor: executor}))
+	require.Len(t, patches, 1)
+	require.Contains(t, <-recorder.Events, "Suspended")
+
+	// The patch only holds the status, without a resource version so that it
+	// can't conflict with concurrent edits of the spec
+	patch := patches[0].(*unstructured.Unstructured)
+	require.Equal(t, spinv1alpha1.GroupVersion.WithKind("SpinApp"), patch.GroupVersionKind())
+	require.Equal(t, app.Name, patch.GetName())
+	require.Empty(t, patch.GetResourceVersion())
+	require.NotContains(t, patch.Object, "spec")
+	observedGeneration, _, err := unstructured.NestedInt64(patch.Object, "status", "observedGeneration")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), observedGeneration)
+
+	opts := &client.SubResourcePatchOptions{}
+	opts.ApplyOptions(patchOptions)
+	require.Equal(t, FieldManager, opts.FieldManager)
+	require.True(t, *opts.Force)
+
+	// The computed status is kept on the app
+	require.Equal(t, int64(2), app.Status.ObservedGeneration)
+	require.True(t, meta.IsStatusConditionTrue(app.Status.Conditions, spinAppConditionSuspended))
+}
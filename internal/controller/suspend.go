@@ -0,0 +1,31 @@
+package controller
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+)
+
+// spinAppConditionSuspended reports whether a SpinApp is suspended and scaled
+// to zero.
+const spinAppConditionSuspended = "Suspended"
+
+// suspendedCondition derives the Suspended condition of a SpinApp from its
+// spec.
+func suspendedCondition(app *spinv1alpha1.SpinApp) metav1.Condition {
+	if app.Spec.Suspend {
+		return metav1.Condition{
+			Type:    spinAppConditionSuspended,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Suspended",
+			Message: "The app is suspended and scaled to zero",
+		}
+	}
+
+	return metav1.Condition{
+		Type:    spinAppConditionSuspended,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NotSuspended",
+		Message: "The app is not suspended",
+	}
+}
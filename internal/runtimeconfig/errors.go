@@ -0,0 +1,98 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtimeconfig
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Reasons for failing to build a runtime config. They are suitable for use as
+// the reason of a status condition.
+const (
+	ReasonSecretNotFound    = "SecretNotFound"
+	ReasonConfigMapNotFound = "ConfigMapNotFound"
+	ReasonKeyNotFound       = "KeyNotFound"
+	ReasonDuplicateStore    = "DuplicateStore"
+	ReasonUnknownOption     = "UnknownOption"
+	ReasonMissingOption     = "MissingOption"
+	ReasonUnsupportedType   = "UnsupportedType"
+)
+
+// Error is returned when a runtime config can't be built because of the way it
+// is configured, e.g because it references a secret that doesn't exist. These
+// errors can only be resolved by the user.
+type Error struct {
+	// Reason is a machine readable reason for the failure.
+	Reason string
+	// Message names the exact object or key that caused the failure.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func secretNotFoundError(name types.NamespacedName) *Error {
+	return &Error{
+		Reason:  ReasonSecretNotFound,
+		Message: fmt.Sprintf("secret %s not found", name),
+	}
+}
+
+func configMapNotFoundError(name types.NamespacedName) *Error {
+	return &Error{
+		Reason:  ReasonConfigMapNotFound,
+		Message: fmt.Sprintf("configmap %s not found", name),
+	}
+}
+
+func keyNotFoundError(kind string, name types.NamespacedName, key string) *Error {
+	return &Error{
+		Reason:  ReasonKeyNotFound,
+		Message: fmt.Sprintf("key %q not found in %s %s", key, kind, name),
+	}
+}
+
+func duplicateStoreError(kind, name string) *Error {
+	return &Error{
+		Reason:  ReasonDuplicateStore,
+		Message: fmt.Sprintf("duplicate definition for %s with name: %s", kind, name),
+	}
+}
+
+func unknownOptionError(kind, name string) *Error {
+	return &Error{
+		Reason:  ReasonUnknownOption,
+		Message: fmt.Sprintf("unknown option for %s: %s", kind, name),
+	}
+}
+
+func missingOptionError(kind, name string) *Error {
+	return &Error{
+		Reason:  ReasonMissingOption,
+		Message: fmt.Sprintf("missing required option for %s: %s", kind, name),
+	}
+}
+
+func unsupportedVariablesProviderError(providerType string) *Error {
+	return &Error{
+		Reason:  ReasonUnsupportedType,
+		Message: fmt.Sprintf("unsupported variables provider type: %s", providerType),
+	}
+}
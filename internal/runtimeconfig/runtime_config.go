@@ -8,9 +8,10 @@
 //  2. For all of the config options we build a map of secrets and config maps
 //     this has the advantage of de-duping whole-secrets into a single reference
 //     when different keys may be re-used.
-//  3. We fetch all of those secrets and config maps, returning an error if any
-//     are not found. (We do not currently support "optional" secrets, as there
-//     are no runtimeConfig options that would make sense to be optional).
+//  3. We fetch all of those secrets and config maps, returning an *Error if any
+//     are not found. Secrets and config maps that are only referenced by
+//     "optional" key selectors may be missing, in which case the options
+//     referencing them are omitted.
 //  4. We then iterate over the RuntimeConfig CRD again, with the augmented data,
 //     to finally build a `runtimeconfig.Spin` that has populated config options.
 //
@@ -70,6 +71,7 @@ import (
 	"github.com/spinkube/spin-operator/internal/logging"
 	"golang.org/x/sync/errgroup"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -116,10 +118,9 @@ func (k *K8sBuilder) Build(ctx context.Context, app *spinv1alpha1.SpinApp) (rc *
 
 	rc = &Spin{}
 
-	// We don't currently expose a VariablesProvider in the CRD as we expose bindings
-	// to Kubernetes ConfigMaps and Secrets directly.
-	// We should consider adding support for configuring alternative Variables
-	// providers in the future.
+	// Variables of the app bind Kubernetes ConfigMaps and Secrets through the
+	// environment, so the env provider always comes first. Other providers are
+	// consulted in the order they're configured in.
 	rc.Variables = []VariablesProvider{
 		{
 			Type: "env",
@@ -131,6 +132,14 @@ func (k *K8sBuilder) Build(ctx context.Context, app *spinv1alpha1.SpinApp) (rc *
 		},
 	}
 
+	for _, provider := range runtimeConfig.VariablesProviders {
+		err := rc.AddVariablesProvider(provider.Type, app.ObjectMeta.Namespace,
+			deps.Secrets, deps.ConfigMaps, provider.Options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	for _, kvStore := range runtimeConfig.KeyValueStores {
 		err := rc.AddKeyValueStore(kvStore.Name, kvStore.Type, app.ObjectMeta.Namespace,
 			deps.Secrets, deps.ConfigMaps, kvStore.Options)
@@ -162,10 +171,17 @@ func (k *K8sBuilder) Build(ctx context.Context, app *spinv1alpha1.SpinApp) (rc *
 type dependencies struct {
 	Secrets    map[types.NamespacedName]*corev1.Secret
 	ConfigMaps map[types.NamespacedName]*corev1.ConfigMap
+
+	// OptionalSecrets and OptionalConfigMaps hold the dependencies that are only
+	// referenced by optional key selectors, and so are allowed to be missing.
+	OptionalSecrets    map[types.NamespacedName]bool
+	OptionalConfigMaps map[types.NamespacedName]bool
 }
 
 // fetch will iterate over all of the Secrets and ConfigMaps in the dependency
-// list and attempt to fetch them. It will fail on the first error.
+// list and attempt to fetch them. It will fail on the first error, returning an
+// *Error naming the object when a required one doesn't exist. Optional ones that
+// don't exist are left without any data.
 func (e *dependencies) fetch(ctx context.Context, client client.Client) error {
 	logger := logging.FromContext(ctx).WithValues("component", "runtime_config_builder")
 
@@ -178,7 +194,14 @@ func (e *dependencies) fetch(ctx context.Context, client client.Client) error {
 
 		g.Go(func() error {
 			logger.Debug("fetching secret", "secret", name)
-			return client.Get(ctx, name, secret)
+			err := client.Get(ctx, name, secret)
+			if apierrors.IsNotFound(err) {
+				if e.OptionalSecrets[name] {
+					return nil
+				}
+				return secretNotFoundError(name)
+			}
+			return err
 		})
 	}
 
@@ -188,7 +211,14 @@ func (e *dependencies) fetch(ctx context.Context, client client.Client) error {
 
 		g.Go(func() error {
 			logger.Debug("fetching config map", "config_map", name)
-			return client.Get(ctx, name, cm)
+			err := client.Get(ctx, name, cm)
+			if apierrors.IsNotFound(err) {
+				if e.OptionalConfigMaps[name] {
+					return nil
+				}
+				return configMapNotFoundError(name)
+			}
+			return err
 		})
 	}
 
@@ -197,8 +227,10 @@ func (e *dependencies) fetch(ctx context.Context, client client.Client) error {
 
 func extractRuntimeConfigDependencies(app *spinv1alpha1.SpinApp) *dependencies {
 	result := &dependencies{
-		Secrets:    make(map[types.NamespacedName]*corev1.Secret),
-		ConfigMaps: make(map[types.NamespacedName]*corev1.ConfigMap),
+		Secrets:            make(map[types.NamespacedName]*corev1.Secret),
+		ConfigMaps:         make(map[types.NamespacedName]*corev1.ConfigMap),
+		OptionalSecrets:    make(map[types.NamespacedName]bool),
+		OptionalConfigMaps: make(map[types.NamespacedName]bool),
 	}
 
 	runtimeConfig := app.Spec.RuntimeConfig
@@ -218,6 +250,9 @@ func extractRuntimeConfigDependencies(app *spinv1alpha1.SpinApp) *dependencies {
 	for _, sqlDB := range runtimeConfig.SqliteDatabases {
 		configOptions = append(configOptions, sqlDB.Options...)
 	}
+	for _, provider := range runtimeConfig.VariablesProviders {
+		configOptions = append(configOptions, provider.Options...)
+	}
 
 	secretMapper := func(configOption spinv1alpha1.RuntimeConfigOption) *corev1.Secret {
 		if configOption.ValueFrom == nil {
@@ -279,5 +314,22 @@ func extractRuntimeConfigDependencies(app *spinv1alpha1.SpinApp) *dependencies {
 		return types.NamespacedName{Name: cm.ObjectMeta.Name, Namespace: cm.ObjectMeta.Namespace}
 	})
 
+	// A dependency is only optional when every reference to it is optional
+	for _, configOption := range configOptions {
+		if configOption.ValueFrom == nil {
+			continue
+		}
+		if ref := configOption.ValueFrom.SecretKeyRef; ref != nil {
+			name := types.NamespacedName{Name: ref.Name, Namespace: app.ObjectMeta.Namespace}
+			optional, seen := result.OptionalSecrets[name]
+			result.OptionalSecrets[name] = (optional || !seen) && isOptional(ref.Optional)
+		}
+		if ref := configOption.ValueFrom.ConfigMapKeyRef; ref != nil {
+			name := types.NamespacedName{Name: ref.Name, Namespace: app.ObjectMeta.Namespace}
+			optional, seen := result.OptionalConfigMaps[name]
+			result.OptionalConfigMaps[name] = (optional || !seen) && isOptional(ref.Optional)
+		}
+	}
+
 	return result
 }
@@ -1,13 +1,16 @@
 package runtimeconfig
 
 import (
+	"context"
 	"testing"
 
+	toml "github.com/pelletier/go-toml/v2"
 	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func secretKeySelector(name string) *corev1.SecretKeySelector {
@@ -233,6 +236,33 @@ func Test_ExtractRuntimeConfigDependencies(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "variables_provider_secrets",
+			inputAppSpec: func() spinv1alpha1.SpinAppSpec {
+				spec := basicSpec
+				spec.RuntimeConfig.VariablesProviders = []spinv1alpha1.VariablesProviderConfig{
+					{
+						Type: "vault",
+						Options: []spinv1alpha1.RuntimeConfigOption{
+							{
+								Name: "token",
+								ValueFrom: &spinv1alpha1.RuntimeConfigVarSource{
+									SecretKeyRef: secretKeySelector("my-vault-secret"),
+								},
+							},
+						},
+					},
+				}
+
+				return spec
+			},
+			expectedSecrets: []types.NamespacedName{
+				{
+					Name:      "my-vault-secret",
+					Namespace: "test-ns",
+				},
+			},
+		},
 	}
 
 	for _, test := range table {
@@ -256,6 +286,253 @@ func Test_ExtractRuntimeConfigDependencies(t *testing.T) {
 	}
 }
 
+func Test_Build_Errors(t *testing.T) {
+	t.Parallel()
+
+	redisURL := spinv1alpha1.RuntimeConfigOption{
+		Name: "url",
+		ValueFrom: &spinv1alpha1.RuntimeConfigVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "redis"},
+				Key:                  "url",
+			},
+		},
+	}
+
+	table := []struct {
+		name            string
+		keyValueStores  []spinv1alpha1.KeyValueStoreConfig
+		objects         []corev1.Secret
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name: "missing_secret",
+			keyValueStores: []spinv1alpha1.KeyValueStoreConfig{
+				{Name: "default", Type: "redis", Options: []spinv1alpha1.RuntimeConfigOption{redisURL}},
+			},
+			expectedReason:  ReasonSecretNotFound,
+			expectedMessage: "secret test-ns/redis not found",
+		},
+		{
+			name: "missing_secret_key",
+			keyValueStores: []spinv1alpha1.KeyValueStoreConfig{
+				{Name: "default", Type: "redis", Options: []spinv1alpha1.RuntimeConfigOption{redisURL}},
+			},
+			objects: []corev1.Secret{{
+				ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: "test-ns"},
+				Data:       map[string][]byte{"uri": []byte("redis://localhost:6379")},
+			}},
+			expectedReason:  ReasonKeyNotFound,
+			expectedMessage: `key "url" not found in secret test-ns/redis`,
+		},
+		{
+			name: "duplicate_store",
+			keyValueStores: []spinv1alpha1.KeyValueStoreConfig{
+				{Name: "default", Type: "redis", Options: []spinv1alpha1.RuntimeConfigOption{redisURL}},
+				{Name: "default", Type: "spin"},
+			},
+			objects: []corev1.Secret{{
+				ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: "test-ns"},
+				Data:       map[string][]byte{"url": []byte("redis://localhost:6379")},
+			}},
+			expectedReason:  ReasonDuplicateStore,
+			expectedMessage: "duplicate definition for key value store with name: default",
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder()
+			for idx := range test.objects {
+				builder = builder.WithObjects(&test.objects[idx])
+			}
+
+			app := &spinv1alpha1.SpinApp{
+				ObjectMeta: metav1.ObjectMeta{Name: test.name, Namespace: "test-ns"},
+				Spec: spinv1alpha1.SpinAppSpec{
+					RuntimeConfig: spinv1alpha1.RuntimeConfig{KeyValueStores: test.keyValueStores},
+				},
+			}
+
+			_, err := NewBuilder(builder.Build()).Build(context.Background(), app)
+			var rcErr *Error
+			require.ErrorAs(t, err, &rcErr)
+			require.Equal(t, test.expectedReason, rcErr.Reason)
+			require.Equal(t, test.expectedMessage, rcErr.Message)
+		})
+	}
+}
+
+func Test_Build_OptionalAndAlternateData(t *testing.T) {
+	t.Parallel()
+
+	option := func(name string, source spinv1alpha1.RuntimeConfigVarSource) spinv1alpha1.RuntimeConfigOption {
+		return spinv1alpha1.RuntimeConfigOption{Name: name, ValueFrom: &source}
+	}
+	optional := true
+
+	client := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: "test-ns"},
+			StringData: map[string]string{"url": "redis://localhost:6379"},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "sqlite", Namespace: "test-ns"},
+			BinaryData: map[string][]byte{"path": []byte("/mnt/store/sqlite.db")},
+		},
+	).Build()
+
+	app := &spinv1alpha1.SpinApp{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: "test-ns"},
+		Spec: spinv1alpha1.SpinAppSpec{
+			RuntimeConfig: spinv1alpha1.RuntimeConfig{
+				KeyValueStores: []spinv1alpha1.KeyValueStoreConfig{{
+					Name: "default",
+					Type: "redis",
+					Options: []spinv1alpha1.RuntimeConfigOption{
+						option("url", spinv1alpha1.RuntimeConfigVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "redis"},
+							Key:                  "url",
+						}}),
+						option("password", spinv1alpha1.RuntimeConfigVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "redis"},
+							Key:                  "password",
+							Optional:             &optional,
+						}}),
+					},
+				}},
+				SqliteDatabases: []spinv1alpha1.SqliteDatabaseConfig{{
+					Name: "default",
+					Type: "spin",
+					Options: []spinv1alpha1.RuntimeConfigOption{
+						option("path", spinv1alpha1.RuntimeConfigVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "sqlite"},
+							Key:                  "path",
+						}}),
+						// The config map doesn't exist, but is only optionally referenced
+						option("token", spinv1alpha1.RuntimeConfigVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "turso"},
+							Key:                  "token",
+							Optional:             &optional,
+						}}),
+					},
+				}},
+			},
+		},
+	}
+
+	rc, err := NewBuilder(client).Build(context.Background(), app)
+	require.NoError(t, err)
+	require.Equal(t, KeyValueStoreOptions{
+		"type": "redis",
+		"url":  "redis://localhost:6379",
+	}, rc.KeyValueStores["default"])
+	require.Equal(t, SQLiteDatabaseOptions{
+		"type": "spin",
+		"path": "/mnt/store/sqlite.db",
+	}, rc.SQLiteDatabases["default"])
+}
+
+func Test_Build_VariablesProviders(t *testing.T) {
+	t.Parallel()
+
+	secretRef := func(name, key string) *spinv1alpha1.RuntimeConfigVarSource {
+		return &spinv1alpha1.RuntimeConfigVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name},
+			Key:                  key,
+		}}
+	}
+
+	client := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "test-ns"},
+			Data:       map[string][]byte{"token": []byte("root")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "azure", Namespace: "test-ns"},
+			Data:       map[string][]byte{"client-secret": []byte("hunter2")},
+		},
+	).Build()
+
+	app := &spinv1alpha1.SpinApp{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: "test-ns"},
+		Spec: spinv1alpha1.SpinAppSpec{
+			RuntimeConfig: spinv1alpha1.RuntimeConfig{
+				VariablesProviders: []spinv1alpha1.VariablesProviderConfig{
+					{
+						Type: "vault",
+						Options: []spinv1alpha1.RuntimeConfigOption{
+							{Name: "url", Value: "http://vault.vault.svc:8200"},
+							{Name: "token", ValueFrom: secretRef("vault", "token")},
+							{Name: "mount", Value: "secret"},
+							{Name: "prefix", Value: "my-app"},
+						},
+					},
+					{
+						Type: "azure_key_vault",
+						Options: []spinv1alpha1.RuntimeConfigOption{
+							{Name: "vault_url", Value: "https://my-vault.vault.azure.net/"},
+							{Name: "client_id", Value: "my-client"},
+							{Name: "client_secret", ValueFrom: secretRef("azure", "client-secret")},
+							{Name: "tenant_id", Value: "my-tenant"},
+							{Name: "authority_host", Value: "AzurePublicCloud"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	rc, err := NewBuilder(client).Build(context.Background(), app)
+	require.NoError(t, err)
+
+	// The env provider comes first, followed by the configured providers in order
+	result, err := toml.Marshal(rc)
+	require.NoError(t, err)
+	require.Equal(t, `[[config_provider]]
+type = 'env'
+prefix = 'SPIN_VARIABLE_'
+
+[[config_provider]]
+type = 'vault'
+prefix = 'my-app'
+url = 'http://vault.vault.svc:8200'
+token = 'root'
+mount = 'secret'
+
+[[config_provider]]
+type = 'azure_key_vault'
+vault_url = 'https://my-vault.vault.azure.net/'
+client_id = 'my-client'
+client_secret = 'hunter2'
+tenant_id = 'my-tenant'
+authority_host = 'AzurePublicCloud'
+`, string(result))
+
+	// Options of other providers are rejected
+	app.Spec.RuntimeConfig.VariablesProviders[0].Options = append(app.Spec.RuntimeConfig.VariablesProviders[0].Options,
+		spinv1alpha1.RuntimeConfigOption{Name: "vault_url", Value: "https://my-vault.vault.azure.net/"})
+	_, err = NewBuilder(client).Build(context.Background(), app)
+	var rcErr *Error
+	require.ErrorAs(t, err, &rcErr)
+	require.Equal(t, ReasonUnknownOption, rcErr.Reason)
+	require.Equal(t, "unknown option for vault variables provider: vault_url", rcErr.Message)
+
+	// Spin rejects providers without their required options, e.g a vault
+	// provider without a mount
+	app.Spec.RuntimeConfig.VariablesProviders[0].Options = app.Spec.RuntimeConfig.VariablesProviders[0].Options[:2]
+	_, err = NewBuilder(client).Build(context.Background(), app)
+	require.ErrorAs(t, err, &rcErr)
+	require.Equal(t, ReasonMissingOption, rcErr.Reason)
+	require.Equal(t, "missing required option for vault variables provider: mount", rcErr.Message)
+
+	app.Spec.RuntimeConfig.VariablesProviders = []spinv1alpha1.VariablesProviderConfig{{Type: "azure_key_vault"}}
+	_, err = NewBuilder(client).Build(context.Background(), app)
+	require.ErrorAs(t, err, &rcErr)
+	require.Equal(t, "missing required option for azure_key_vault variables provider: vault_url", rcErr.Message)
+}
+
 func mapKeys[T comparable, V any, M ~map[T]V](input M) []T {
 	result := make([]T, 0, len(input))
 	for key := range input {
@@ -1,8 +1,6 @@
 package runtimeconfig
 
 import (
-	"fmt"
-
 	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
 	"github.com/spinkube/spin-operator/pkg/secret"
 	corev1 "k8s.io/api/core/v1"
@@ -14,9 +12,28 @@ type EnvVariablesProviderOptions struct {
 	DotEnvPath string `toml:"dotenv_path,omitempty"`
 }
 
+// VaultVariablesProviderOptions configures a HashiCorp Vault variables provider.
+// Its optional prefix is rendered from EnvVariablesProviderOptions.Prefix, as
+// both providers call it "prefix".
+type VaultVariablesProviderOptions struct {
+	URL   secret.String `toml:"url,omitempty"`
+	Token secret.String `toml:"token,omitempty"`
+	Mount secret.String `toml:"mount,omitempty"`
+}
+
+type AzureKeyVaultVariablesProviderOptions struct {
+	VaultURL      secret.String `toml:"vault_url,omitempty"`
+	ClientID      secret.String `toml:"client_id,omitempty"`
+	ClientSecret  secret.String `toml:"client_secret,omitempty"`
+	TenantID      secret.String `toml:"tenant_id,omitempty"`
+	AuthorityHost secret.String `toml:"authority_host,omitempty"`
+}
+
 type VariablesProvider struct {
 	Type string `toml:"type,omitempty"`
 	EnvVariablesProviderOptions
+	VaultVariablesProviderOptions
+	AzureKeyVaultVariablesProviderOptions
 }
 
 type KeyValueStoreOptions map[string]secret.String
@@ -43,7 +60,7 @@ func (s *Spin) AddKeyValueStore(
 	}
 
 	if _, ok := s.KeyValueStores[name]; ok {
-		return fmt.Errorf("duplicate definition for key value store with name: %s", name)
+		return duplicateStoreError("key value store", name)
 	}
 
 	options, err := renderOptionsIntoMap(storeType, namespace, opts, secrets, configMaps)
@@ -64,7 +81,7 @@ func (s *Spin) AddSQLiteDatabase(
 		s.SQLiteDatabases = make(map[string]SQLiteDatabaseOptions)
 	}
 	if _, ok := s.SQLiteDatabases[name]; ok {
-		return fmt.Errorf("duplicate definition for sqlite database with name: %s", name)
+		return duplicateStoreError("sqlite database", name)
 	}
 
 	options, err := renderOptionsIntoMap(storeType, namespace, opts, secrets, configMaps)
@@ -89,6 +106,70 @@ func (s *Spin) AddLLMCompute(computeType, namespace string,
 	return nil
 }
 
+// AddVariablesProvider adds a vault or azure_key_vault variables provider after
+// the providers that were already added.
+func (s *Spin) AddVariablesProvider(providerType, namespace string,
+	secrets map[types.NamespacedName]*corev1.Secret,
+	configMaps map[types.NamespacedName]*corev1.ConfigMap,
+	opts []spinv1alpha1.RuntimeConfigOption) error {
+	options, err := renderOptionsIntoMap(providerType, namespace, opts, secrets, configMaps)
+	if err != nil {
+		return err
+	}
+
+	provider := VariablesProvider{Type: providerType}
+	var fields map[string]*secret.String
+	var required []string
+	switch providerType {
+	case "vault":
+		vault := &provider.VaultVariablesProviderOptions
+		fields = map[string]*secret.String{
+			"url":   &vault.URL,
+			"token": &vault.Token,
+			"mount": &vault.Mount,
+		}
+		required = []string{"url", "token", "mount"}
+	case "azure_key_vault":
+		azure := &provider.AzureKeyVaultVariablesProviderOptions
+		fields = map[string]*secret.String{
+			"vault_url":      &azure.VaultURL,
+			"client_id":      &azure.ClientID,
+			"client_secret":  &azure.ClientSecret,
+			"tenant_id":      &azure.TenantID,
+			"authority_host": &azure.AuthorityHost,
+		}
+		required = []string{"vault_url"}
+	default:
+		return unsupportedVariablesProviderError(providerType)
+	}
+
+	for name, value := range options {
+		switch {
+		case name == "type":
+		case name == "prefix" && providerType == "vault":
+			provider.Prefix = value.Value()
+		case fields[name] != nil:
+			*fields[name] = value
+		default:
+			return unknownOptionError(providerType+" variables provider", name)
+		}
+	}
+
+	// Spin rejects providers without these when the app starts
+	for _, name := range required {
+		if *fields[name] == "" {
+			return missingOptionError(providerType+" variables provider", name)
+		}
+	}
+
+	s.Variables = append(s.Variables, provider)
+	return nil
+}
+
+// renderOptionsIntoMap renders the options of a runtime config entry, resolving
+// values from the given secrets and config maps. A referenced key that doesn't
+// exist is an error unless the reference is optional, in which case the option
+// is omitted.
 func renderOptionsIntoMap(typeOpt, namespace string,
 	opts []spinv1alpha1.RuntimeConfigOption,
 	secrets map[types.NamespacedName]*corev1.Secret, configMaps map[types.NamespacedName]*corev1.ConfigMap) (map[string]secret.String, error) {
@@ -102,21 +183,35 @@ func renderOptionsIntoMap(typeOpt, namespace string,
 			value = opt.Value
 		} else if valueFrom := opt.ValueFrom; valueFrom != nil {
 			if cmKeyRef := valueFrom.ConfigMapKeyRef; cmKeyRef != nil {
-				cm, ok := configMaps[types.NamespacedName{Name: cmKeyRef.Name, Namespace: namespace}]
+				name := types.NamespacedName{Name: cmKeyRef.Name, Namespace: namespace}
+				cm, ok := configMaps[name]
 				if !ok {
 					// This error shouldn't happen - we validate dependencies ahead of time, add this as a fallback error
-					return nil, fmt.Errorf("unmet dependency while building config: configmap (%s/%s) not found", namespace, cmKeyRef.Name)
+					return nil, configMapNotFoundError(name)
 				}
 
-				value = cm.Data[cmKeyRef.Key]
+				value, ok = configMapValue(cm, cmKeyRef.Key)
+				if !ok {
+					if isOptional(cmKeyRef.Optional) {
+						continue
+					}
+					return nil, keyNotFoundError("configmap", name, cmKeyRef.Key)
+				}
 			} else if secKeyRef := valueFrom.SecretKeyRef; secKeyRef != nil {
-				sec, ok := secrets[types.NamespacedName{Name: secKeyRef.Name, Namespace: namespace}]
+				name := types.NamespacedName{Name: secKeyRef.Name, Namespace: namespace}
+				sec, ok := secrets[name]
 				if !ok {
 					// This error shouldn't happen - we validate dependencies ahead of time, add this as a fallback error
-					return nil, fmt.Errorf("unmet dependency while building config: secret (%s/%s) not found", namespace, secKeyRef.Name)
+					return nil, secretNotFoundError(name)
 				}
 
-				value = string(sec.Data[secKeyRef.Key])
+				value, ok = secretValue(sec, secKeyRef.Key)
+				if !ok {
+					if isOptional(secKeyRef.Optional) {
+						continue
+					}
+					return nil, keyNotFoundError("secret", name, secKeyRef.Key)
+				}
 			}
 		}
 
@@ -125,3 +220,31 @@ func renderOptionsIntoMap(typeOpt, namespace string,
 
 	return options, nil
 }
+
+// configMapValue looks up a key in both the Data and BinaryData of a config map.
+func configMapValue(cm *corev1.ConfigMap, key string) (string, bool) {
+	if value, ok := cm.Data[key]; ok {
+		return value, true
+	}
+	if value, ok := cm.BinaryData[key]; ok {
+		return string(value), true
+	}
+	return "", false
+}
+
+// secretValue looks up a key in both the Data and StringData of a secret.
+// StringData is write-only in the API, but is set on secrets that haven't been
+// persisted yet.
+func secretValue(sec *corev1.Secret, key string) (string, bool) {
+	if value, ok := sec.Data[key]; ok {
+		return string(value), true
+	}
+	if value, ok := sec.StringData[key]; ok {
+		return value, true
+	}
+	return "", false
+}
+
+func isOptional(optional *bool) bool {
+	return optional != nil && *optional
+}
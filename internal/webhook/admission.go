@@ -3,12 +3,19 @@ package webhook
 import (
 	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 func SetupSpinAppWebhookWithManager(mgr ctrl.Manager) error {
+	// The defaulter is registered by hand rather than with WithDefaulter so that
+	// it can return warnings.
+	defaulter := admission.WithCustomDefaulter(mgr.GetScheme(), &spinv1alpha1.SpinApp{}, &SpinAppDefaulter{Client: mgr.GetClient()})
+	mgr.GetWebhookServer().Register("/mutate-core-spinkube-dev-v1alpha1-spinapp", &admission.Webhook{
+		Handler: warningHandler{Handler: defaulter},
+	})
+
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(&spinv1alpha1.SpinApp{}).
-		WithDefaulter(&SpinAppDefaulter{Client: mgr.GetClient()}).
 		WithValidator(&SpinAppValidator{Client: mgr.GetClient()}).
 		Complete()
 }
@@ -20,3 +27,10 @@ func SetupSpinAppExecutorWebhookWithManager(mgr ctrl.Manager) error {
 		WithValidator(&SpinAppExecutorValidator{Client: mgr.GetClient()}).
 		Complete()
 }
+
+func SetupClusterSpinAppExecutorWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&spinv1alpha1.ClusterSpinAppExecutor{}).
+		WithValidator(&ClusterSpinAppExecutorValidator{Client: mgr.GetClient()}).
+		Complete()
+}
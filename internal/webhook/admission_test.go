@@ -105,6 +105,9 @@ func startWebhookServer(t *testing.T, envtest *envTestState) {
 	err = SetupSpinAppExecutorWebhookWithManager(mgr)
 	require.NoError(t, err)
 
+	err = SetupClusterSpinAppExecutorWebhookWithManager(mgr)
+	require.NoError(t, err)
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	go func() {
@@ -237,6 +240,40 @@ func TestCreateSpinAppWithMultipleExecutors(t *testing.T) {
 	require.Equal(t, int32(2), spinapp.Spec.Replicas)
 }
 
+func TestCreateSpinAppWithClusterExecutor(t *testing.T) {
+	t.Parallel()
+
+	envtest := setupEnvTest(t)
+	startWebhookServer(t, envtest)
+
+	err := envtest.k8sClient.Create(context.Background(), &spinv1alpha1.ClusterSpinAppExecutor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "containerd-shim-spin",
+		},
+	})
+	require.NoError(t, err)
+
+	err = envtest.k8sClient.Create(context.Background(), &spinv1alpha1.SpinApp{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "spinapp",
+			Namespace: "default",
+		},
+		Spec: spinv1alpha1.SpinAppSpec{
+			Image:    "ghcr.io/spinkube/containerd-shim-spin/examples/spin-rust-hello:v0.13.0",
+			Replicas: 2,
+		},
+	})
+	require.NoError(t, err)
+
+	spinapp := &spinv1alpha1.SpinApp{}
+	err = envtest.k8sClient.Get(context.Background(), client.ObjectKey{
+		Name:      "spinapp",
+		Namespace: "default",
+	}, spinapp)
+	require.NoError(t, err)
+	require.Equal(t, constants.ContainerDShimSpinExecutor, spinapp.Spec.Executor)
+}
+
 func TestCreateInvalidSpinApp(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"context"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/generics"
+	"github.com/spinkube/spin-operator/internal/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// nolint:lll
+//+kubebuilder:webhook:path=/validate-core-spinkube-dev-v1alpha1-clusterspinappexecutor,mutating=false,failurePolicy=fail,sideEffects=None,groups=core.spinkube.dev,resources=clusterspinappexecutors,verbs=create;update,versions=v1alpha1,name=vclusterspinappexecutor.kb.io,admissionReviewVersions=v1
+
+// ClusterSpinAppExecutorValidator validates ClusterSpinAppExecutors
+type ClusterSpinAppExecutorValidator struct {
+	Client client.Client
+}
+
+// ValidateCreate implements webhook.Validator
+func (v *ClusterSpinAppExecutorValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	log := logging.FromContext(ctx)
+
+	executor := obj.(*spinv1alpha1.ClusterSpinAppExecutor)
+	log.Info("validate create", "name", executor.Name)
+
+	return nil, v.validateClusterSpinAppExecutor(ctx, executor)
+}
+
+// ValidateUpdate implements webhook.Validator
+func (v *ClusterSpinAppExecutorValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	log := logging.FromContext(ctx)
+
+	executor := newObj.(*spinv1alpha1.ClusterSpinAppExecutor)
+	log.Info("validate update", "name", executor.Name)
+
+	return nil, v.validateClusterSpinAppExecutor(ctx, executor)
+}
+
+// ValidateDelete implements webhook.Validator
+func (v *ClusterSpinAppExecutorValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	log := logging.FromContext(ctx)
+
+	executor := obj.(*spinv1alpha1.ClusterSpinAppExecutor)
+	log.Info("validate delete", "name", executor.Name)
+
+	return nil, nil
+}
+
+func (v *ClusterSpinAppExecutorValidator) validateClusterSpinAppExecutor(ctx context.Context, executor *spinv1alpha1.ClusterSpinAppExecutor) error {
+	var allErrs field.ErrorList
+
+	if err := validateRuntimeClassAndSpinImage(&executor.Spec); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if config := executor.Spec.DeploymentConfig; config != nil {
+		if err := validateRolloutControls(field.NewPath("spec").Child("deploymentConfig"),
+			config.RolloutControls, spinv1alpha1.RolloutControls{}); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	if isDefaultExecutor(executor) {
+		var executors spinv1alpha1.ClusterSpinAppExecutorList
+		if err := v.Client.List(ctx, &executors); err != nil {
+			return err
+		}
+
+		others := generics.MapList(executors.Items, func(e spinv1alpha1.ClusterSpinAppExecutor) metav1.Object { return &e })
+		if err := validateSingleDefaultExecutor(executor, others); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: "core.spinkube.dev", Kind: "ClusterSpinAppExecutor"},
+		executor.Name, allErrs)
+}
@@ -2,10 +2,16 @@ package webhook
 
 import (
 	"context"
-	"strings"
+	"fmt"
+	"slices"
 
 	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/constants"
+	"github.com/spinkube/spin-operator/internal/generics"
 	"github.com/spinkube/spin-operator/internal/logging"
+	"github.com/spinkube/spin-operator/pkg/spinapp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -26,44 +32,106 @@ func (d *SpinAppDefaulter) Default(ctx context.Context, obj runtime.Object) erro
 	log.Info("default", "name", spinApp.Name)
 
 	if spinApp.Spec.Executor == "" {
-		executor, err := d.findDefaultExecutor(ctx)
+		executor, err := d.findDefaultExecutor(ctx, spinApp.Namespace)
 		if err != nil {
 			return err
 		}
 		spinApp.Spec.Executor = executor
 	}
 
+	if spinApp.Spec.ListenPort == 0 && spinApp.Spec.Executor != "" {
+		listenPort, err := d.defaultListenPort(ctx, spinApp)
+		if err != nil {
+			return err
+		}
+		spinApp.Spec.ListenPort = listenPort
+	}
+
 	return nil
 }
 
+// defaultListenPort returns the listen port of the executor of a SpinApp,
+// falling back to spinapp.DefaultHTTPPort. The port is left unset if the
+// executor doesn't exist, which is rejected by validation.
+func (d *SpinAppDefaulter) defaultListenPort(ctx context.Context, spinApp *spinv1alpha1.SpinApp) (int32, error) {
+	executor, err := getExecutor(ctx, d.Client, spinApp.Namespace, spinApp.Spec.Executor)
+	if apierrors.IsNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if config := executor.Spec.DeploymentConfig; config != nil && config.ListenPort != nil {
+		return *config.ListenPort, nil
+	}
+	return spinapp.DefaultHTTPPort, nil
+}
+
 // findDefaultExecutor sets the default executor for a SpinApp.
 //
-// Defaults to whatever executor is available on the cluster. If multiple
-// executors are available then the first executor in alphabetical order
-// will be chosen. If no executors are available then no default will be set.
-func (d *SpinAppDefaulter) findDefaultExecutor(ctx context.Context) (string, error) {
+// An executor marked with the constants.DefaultExecutorAnnotationKey
+// annotation is preferred, first in the namespace of the app and then across
+// ClusterSpinAppExecutors. If no executor is marked then we fall back to
+// whatever executor is available in the namespace, and then in the cluster. If
+// multiple executors are available then the first executor in alphabetical
+// order will be chosen and a warning is returned. If no executors are
+// available then no default will be set.
+func (d *SpinAppDefaulter) findDefaultExecutor(ctx context.Context, namespace string) (string, error) {
 	log := logging.FromContext(ctx)
 
 	var executors spinv1alpha1.SpinAppExecutorList
-	if err := d.Client.List(ctx, &executors); err != nil {
+	if err := d.Client.List(ctx, &executors, client.InNamespace(namespace)); err != nil {
 		log.Error(err, "failed to list SpinAppExecutors")
 		return "", err
 	}
 
-	if len(executors.Items) == 0 {
-		log.Info("no SpinAppExecutors found")
-		return "", nil
+	var clusterExecutors spinv1alpha1.ClusterSpinAppExecutorList
+	if err := d.Client.List(ctx, &clusterExecutors); err != nil {
+		log.Error(err, "failed to list ClusterSpinAppExecutors")
+		return "", err
+	}
+
+	scopes := [][]metav1.Object{
+		generics.MapList(executors.Items, func(e spinv1alpha1.SpinAppExecutor) metav1.Object { return &e }),
+		generics.MapList(clusterExecutors.Items, func(e spinv1alpha1.ClusterSpinAppExecutor) metav1.Object { return &e }),
+	}
+
+	// Prefer an executor that has explicitly been marked as the default
+	for _, scope := range scopes {
+		marked := slices.DeleteFunc(slices.Clone(scope), func(e metav1.Object) bool { return !isDefaultExecutor(e) })
+		if len(marked) > 0 {
+			chosenExecutor := slices.Min(executorNames(marked))
+			log.Info("defaulting to executor marked as default", "name", chosenExecutor)
+			return chosenExecutor, nil
+		}
 	}
 
-	// Return first executor in alphabetical order
-	chosenExecutor := executors.Items[0]
-	for _, executor := range executors.Items[1:] {
-		// For each item after the first see if it is alphabetically before the current chosen executor
-		if strings.Compare(executor.Name, chosenExecutor.Name) < 0 {
-			chosenExecutor = executor
+	for _, scope := range scopes {
+		if len(scope) == 0 {
+			continue
+		}
+
+		// Return first executor in alphabetical order
+		chosenExecutor := slices.Min(executorNames(scope))
+		if len(scope) > 1 {
+			addWarning(ctx, fmt.Sprintf("no executor is annotated with %s=true, defaulting to %q as it is first in alphabetical order",
+				constants.DefaultExecutorAnnotationKey, chosenExecutor))
 		}
+
+		log.Info("defaulting to executor", "name", chosenExecutor)
+		return chosenExecutor, nil
 	}
 
-	log.Info("defaulting to executor", "name", chosenExecutor.Name)
-	return chosenExecutor.Name, nil
+	log.Info("no SpinAppExecutors or ClusterSpinAppExecutors found")
+	return "", nil
+}
+
+// isDefaultExecutor returns whether an executor is marked as the default.
+func isDefaultExecutor(executor metav1.Object) bool {
+	return executor.GetAnnotations()[constants.DefaultExecutorAnnotationKey] == "true"
+}
+
+func executorNames(executors []metav1.Object) []string {
+	return generics.MapList(executors, func(e metav1.Object) string { return e.GetName() })
 }
@@ -6,7 +6,11 @@ import (
 
 	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
 	"github.com/spinkube/spin-operator/internal/constants"
+	"github.com/spinkube/spin-operator/internal/generics"
 	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestDefaultNothingToSet(t *testing.T) {
@@ -15,12 +19,119 @@ func TestDefaultNothingToSet(t *testing.T) {
 	defaulter := &SpinAppDefaulter{}
 
 	spinApp := &spinv1alpha1.SpinApp{Spec: spinv1alpha1.SpinAppSpec{
-		Executor: constants.CyclotronExecutor,
-		Replicas: 1,
+		Executor:   constants.CyclotronExecutor,
+		Replicas:   1,
+		ListenPort: 8080,
 	}}
 
 	err := defaulter.Default(context.Background(), spinApp)
 	require.NoError(t, err)
 	require.Equal(t, constants.CyclotronExecutor, spinApp.Spec.Executor)
 	require.Equal(t, int32(1), spinApp.Spec.Replicas)
+	require.Equal(t, int32(8080), spinApp.Spec.ListenPort)
+}
+
+func TestFindDefaultExecutor(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	executor := func(name, namespace string) *spinv1alpha1.SpinAppExecutor {
+		return &spinv1alpha1.SpinAppExecutor{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+	clusterExecutor := func(name string) *spinv1alpha1.ClusterSpinAppExecutor {
+		return &spinv1alpha1.ClusterSpinAppExecutor{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+
+	defaulter := &SpinAppDefaulter{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		executor(constants.CyclotronExecutor, "default"),
+		executor(constants.ContainerDShimSpinExecutor, "other"),
+		clusterExecutor("cluster-executor"),
+	).Build()}
+
+	// Namespaced executors take precedence
+	chosen, err := defaulter.findDefaultExecutor(context.Background(), "default")
+	require.NoError(t, err)
+	require.Equal(t, constants.CyclotronExecutor, chosen)
+
+	// Executors in other namespaces are ignored
+	chosen, err = defaulter.findDefaultExecutor(context.Background(), "other")
+	require.NoError(t, err)
+	require.Equal(t, constants.ContainerDShimSpinExecutor, chosen)
+
+	// Falls back to cluster executors
+	chosen, err = defaulter.findDefaultExecutor(context.Background(), "empty")
+	require.NoError(t, err)
+	require.Equal(t, "cluster-executor", chosen)
+
+	// No executors at all
+	defaulter = &SpinAppDefaulter{Client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+	chosen, err = defaulter.findDefaultExecutor(context.Background(), "default")
+	require.NoError(t, err)
+	require.Empty(t, chosen)
+
+	// Executors marked as the default win over alphabetical order, and the
+	// alphabetical fallback warns when there's more than one executor
+	markedExecutor := executor("zzz", "marked")
+	markedExecutor.Annotations = map[string]string{constants.DefaultExecutorAnnotationKey: "true"}
+	defaulter = &SpinAppDefaulter{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		executor("aaa", "marked"),
+		markedExecutor,
+		executor("bbb", "unmarked"),
+		executor("aaa", "unmarked"),
+	).Build()}
+
+	collector := &warningCollector{}
+	ctx := context.WithValue(context.Background(), warningsKey{}, collector)
+
+	chosen, err = defaulter.findDefaultExecutor(ctx, "marked")
+	require.NoError(t, err)
+	require.Equal(t, "zzz", chosen)
+	require.Empty(t, collector.warnings)
+
+	chosen, err = defaulter.findDefaultExecutor(ctx, "unmarked")
+	require.NoError(t, err)
+	require.Equal(t, "aaa", chosen)
+	require.Len(t, collector.warnings, 1)
+	require.Contains(t, collector.warnings[0], constants.DefaultExecutorAnnotationKey)
+}
+
+func TestDefaultListenPort(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	defaulter := &SpinAppDefaulter{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&spinv1alpha1.SpinAppExecutor{
+			ObjectMeta: metav1.ObjectMeta{Name: "unprivileged", Namespace: "default"},
+			Spec: spinv1alpha1.SpinAppExecutorSpec{
+				CreateDeployment: true,
+				DeploymentConfig: &spinv1alpha1.ExecutorDeploymentConfig{ListenPort: generics.Ptr(int32(8080))},
+			},
+		},
+		&spinv1alpha1.ClusterSpinAppExecutor{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-executor"},
+			Spec:       spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true},
+		},
+	).Build()}
+
+	defaulted := func(executor string, listenPort int32) int32 {
+		spinApp := &spinv1alpha1.SpinApp{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			Spec:       spinv1alpha1.SpinAppSpec{Executor: executor, ListenPort: listenPort},
+		}
+		require.NoError(t, defaulter.Default(context.Background(), spinApp))
+		return spinApp.Spec.ListenPort
+	}
+
+	// The port of the executor is used
+	require.Equal(t, int32(8080), defaulted("unprivileged", 0))
+	// Existing apps, and apps on executors without a port, keep port 80
+	require.Equal(t, int32(80), defaulted("cluster-executor", 0))
+	// Apps can override the port of the executor
+	require.Equal(t, int32(9000), defaulted("unprivileged", 9000))
+	// Missing executors are reported by validation
+	require.Equal(t, int32(0), defaulted("missing", 0))
 }
@@ -2,12 +2,22 @@ package webhook
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
 
 	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
 	"github.com/spinkube/spin-operator/internal/logging"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -29,7 +39,7 @@ func (v *SpinAppValidator) ValidateCreate(ctx context.Context, obj runtime.Objec
 	spinApp := obj.(*spinv1alpha1.SpinApp)
 	log.Info("validate create", "name", spinApp.Name)
 
-	return nil, v.validateSpinApp(ctx, spinApp)
+	return v.validateSpinApp(ctx, spinApp, nil)
 }
 
 // ValidateUpdate implements webhook.Validator
@@ -39,7 +49,7 @@ func (v *SpinAppValidator) ValidateUpdate(ctx context.Context, oldObj, newObj ru
 	spinApp := newObj.(*spinv1alpha1.SpinApp)
 	log.Info("validate update", "name", spinApp.Name)
 
-	return nil, v.validateSpinApp(ctx, spinApp)
+	return v.validateSpinApp(ctx, spinApp, oldObj.(*spinv1alpha1.SpinApp))
 }
 
 // ValidateDelete implements webhook.Validator
@@ -52,39 +62,92 @@ func (v *SpinAppValidator) ValidateDelete(ctx context.Context, obj runtime.Objec
 	return nil, nil
 }
 
-func (v *SpinAppValidator) validateSpinApp(ctx context.Context, spinApp *spinv1alpha1.SpinApp) error {
+// validateSpinApp validates a SpinApp. oldSpinApp is nil on create.
+func (v *SpinAppValidator) validateSpinApp(ctx context.Context, spinApp, oldSpinApp *spinv1alpha1.SpinApp) (admission.Warnings, error) {
 	var allErrs field.ErrorList
 	executor, err := validateExecutor(spinApp.Spec, v.fetchExecutor(ctx, spinApp.Namespace))
 	if err != nil {
 		allErrs = append(allErrs, err)
 	}
-	if err := validateReplicas(spinApp.Spec); err != nil {
+	var oldSpec *spinv1alpha1.SpinAppSpec
+	if oldSpinApp != nil {
+		oldSpec = &oldSpinApp.Spec
+	}
+	if err := validateReplicas(spinApp.Spec, oldSpec); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if err := validateAutoscalerTarget(spinApp.Spec); err != nil {
 		allErrs = append(allErrs, err)
 	}
 	if err := validateAnnotations(spinApp.Spec, executor); err != nil {
 		allErrs = append(allErrs, err)
 	}
+	if err := validateAutoscaling(spinApp.Spec, executor); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if err := validateSuspend(spinApp.Spec, executor); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if err := validatePodScheduling(spinApp.Spec, executor); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if err := validateAppRolloutControls(spinApp.Spec, executor); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if err := validateRollout(spinApp.Spec, executor); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if err := validateDisruptionBudget(spinApp.Spec, executor); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if err := validateService(spinApp.Spec, oldSpec); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if err := validateExposure(spinApp.Spec); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	warnings := securityContextWarnings(spinApp.Spec, executor)
 	if len(allErrs) == 0 {
-		return nil
+		return warnings, nil
 	}
 
-	return apierrors.NewInvalid(
+	return warnings, apierrors.NewInvalid(
 		schema.GroupKind{Group: "core.spinkube.dev", Kind: "SpinApp"},
 		spinApp.Name, allErrs)
 }
 
 // fetchExecutor returns a function that fetches a named executor in the provided namespace.
-//
-// We assume that the executor must exist in the same namespace as the SpinApp.
 func (v *SpinAppValidator) fetchExecutor(ctx context.Context, spinAppNs string) func(name string) (*spinv1alpha1.SpinAppExecutor, error) {
 	return func(name string) (*spinv1alpha1.SpinAppExecutor, error) {
-		var executor spinv1alpha1.SpinAppExecutor
-		if err := v.Client.Get(ctx, client.ObjectKey{Name: name, Namespace: spinAppNs}, &executor); err != nil {
-			return nil, err
-		}
+		return getExecutor(ctx, v.Client, spinAppNs, name)
+	}
+}
 
+// getExecutor fetches the executor a SpinApp in the provided namespace refers
+// to by name.
+//
+// An executor in the same namespace as the SpinApp takes precedence, falling
+// back to a ClusterSpinAppExecutor with the same name. Cluster executors are
+// returned as a SpinAppExecutor with the same name and spec.
+func getExecutor(ctx context.Context, c client.Client, namespace, name string) (*spinv1alpha1.SpinAppExecutor, error) {
+	var executor spinv1alpha1.SpinAppExecutor
+	err := c.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, &executor)
+	if err == nil {
 		return &executor, nil
 	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	var clusterExecutor spinv1alpha1.ClusterSpinAppExecutor
+	if err := c.Get(ctx, client.ObjectKey{Name: name}, &clusterExecutor); err != nil {
+		return nil, err
+	}
+
+	return &spinv1alpha1.SpinAppExecutor{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterExecutor.Name},
+		Spec:       clusterExecutor.Spec,
+	}, nil
 }
 
 func validateExecutor(spec spinv1alpha1.SpinAppSpec, fetchExecutor func(name string) (*spinv1alpha1.SpinAppExecutor, error)) (*spinv1alpha1.SpinAppExecutor, *field.Error) {
@@ -97,14 +160,25 @@ func validateExecutor(spec spinv1alpha1.SpinAppSpec, fetchExecutor func(name str
 	executor, err := fetchExecutor(spec.Executor)
 	if err != nil {
 		// Handle errors that are not just "Not Found"
-		return nil, field.Invalid(field.NewPath("spec").Child("executor"), spec.Executor, "executor does not exist in namespace")
+		return nil, field.Invalid(field.NewPath("spec").Child("executor"), spec.Executor, "executor does not exist in namespace or cluster")
 	}
 
 	return executor, nil
 }
 
-func validateReplicas(spec spinv1alpha1.SpinAppSpec) *field.Error {
-	if spec.EnableAutoscaling && spec.Replicas != 0 {
+// validateReplicas validates the replica count of a SpinApp. oldSpec is nil on
+// create.
+//
+// Autoscalers set replicas through the scale subresource, which isn't subject
+// to admission by this webhook, so replicas that are left unchanged by an
+// update are allowed when autoscaling is enabled. Apps whose autoscaler targets
+// the SpinApp set the replica count to start from.
+func validateReplicas(spec spinv1alpha1.SpinAppSpec, oldSpec *spinv1alpha1.SpinAppSpec) *field.Error {
+	if spec.EnableAutoscaling && spec.Replicas < 0 {
+		return field.Invalid(field.NewPath("spec").Child("replicas"), spec.Replicas, "replicas must be >= 0")
+	}
+	if spec.EnableAutoscaling && spec.Replicas != 0 && spec.AutoscalerTarget != spinv1alpha1.AutoscalerTargetSpinApp &&
+		(oldSpec == nil || oldSpec.Replicas != spec.Replicas) {
 		return field.Invalid(field.NewPath("spec").Child("replicas"), spec.Replicas, "replicas cannot be set when autoscaling is enabled")
 	}
 	if !spec.EnableAutoscaling && spec.Replicas < 1 {
@@ -137,3 +211,505 @@ func validateAnnotations(spec spinv1alpha1.SpinAppSpec, executor *spinv1alpha1.S
 
 	return nil
 }
+
+// validateAutoscalerTarget validates the target of the external autoscaler of
+// a SpinApp.
+func validateAutoscalerTarget(spec spinv1alpha1.SpinAppSpec) *field.Error {
+	if spec.AutoscalerTarget == "" {
+		return nil
+	}
+
+	path := field.NewPath("spec").Child("autoscalerTarget")
+	if !spec.EnableAutoscaling {
+		return field.Forbidden(path, "autoscalerTarget can't be set unless enableAutoscaling is true")
+	}
+	if spec.Autoscaling != nil {
+		return field.Forbidden(path, "autoscalerTarget can't be set together with autoscaling, which always targets the SpinApp")
+	}
+
+	return nil
+}
+
+// validateAutoscaling validates the operator managed autoscaling configuration
+// of a SpinApp.
+func validateAutoscaling(spec spinv1alpha1.SpinAppSpec, executor *spinv1alpha1.SpinAppExecutor) *field.Error {
+	autoscaling := spec.Autoscaling
+	if autoscaling == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec").Child("autoscaling")
+	if !spec.EnableAutoscaling {
+		return field.Forbidden(path, "autoscaling can't be configured unless enableAutoscaling is true")
+	}
+
+	// The executor is validated separately
+	if executor != nil && !executor.Spec.CreateDeployment {
+		return field.Forbidden(path, "autoscaling can't be configured when the executor does not use operator deployments")
+	}
+
+	if autoscaling.MinReplicas != nil && *autoscaling.MinReplicas > autoscaling.MaxReplicas {
+		return field.Invalid(path.Child("minReplicas"), *autoscaling.MinReplicas, "minReplicas must be <= maxReplicas")
+	}
+
+	// Only KEDA can scale to zero
+	if autoscaling.MinReplicas != nil && *autoscaling.MinReplicas == 0 && autoscaling.KEDA == nil {
+		return field.Invalid(path.Child("minReplicas"), *autoscaling.MinReplicas, "minReplicas can only be 0 when scaling with keda")
+	}
+
+	hasTargets := autoscaling.TargetCPUUtilizationPercentage != nil || autoscaling.TargetMemoryUtilizationPercentage != nil
+	if autoscaling.KEDA != nil {
+		if !hasTargets && len(autoscaling.KEDA.Triggers) == 0 {
+			return field.Required(path.Child("keda").Child("triggers"),
+				"at least one trigger, targetCPUUtilizationPercentage or targetMemoryUtilizationPercentage must be set")
+		}
+	} else if !hasTargets {
+		return field.Required(path, "at least one of targetCPUUtilizationPercentage or targetMemoryUtilizationPercentage must be set")
+	}
+
+	// Requests default to limits, so either can be used as the basis for
+	// utilization.
+	hasResource := func(name corev1.ResourceName) bool {
+		_, hasRequest := spec.Resources.Requests[name]
+		_, hasLimit := spec.Resources.Limits[name]
+		return hasRequest || hasLimit
+	}
+	if autoscaling.TargetCPUUtilizationPercentage != nil && !hasResource(corev1.ResourceCPU) {
+		return field.Required(field.NewPath("spec").Child("resources").Child("requests").Key(string(corev1.ResourceCPU)),
+			"cpu requests must be set when targetCPUUtilizationPercentage is used")
+	}
+	if autoscaling.TargetMemoryUtilizationPercentage != nil && !hasResource(corev1.ResourceMemory) {
+		return field.Required(field.NewPath("spec").Child("resources").Child("requests").Key(string(corev1.ResourceMemory)),
+			"memory requests must be set when targetMemoryUtilizationPercentage is used")
+	}
+
+	if autoscaling.ScaleDownStabilizationWindowSeconds != nil && autoscaling.Behavior != nil &&
+		autoscaling.Behavior.ScaleDown != nil && autoscaling.Behavior.ScaleDown.StabilizationWindowSeconds != nil {
+		return field.Invalid(path.Child("scaleDownStabilizationWindowSeconds"), *autoscaling.ScaleDownStabilizationWindowSeconds,
+			"scaleDownStabilizationWindowSeconds and behavior.scaleDown.stabilizationWindowSeconds are mutually exclusive")
+	}
+
+	return nil
+}
+
+// validatePodScheduling validates that scheduling constraints are only set for
+// apps whose pods are created by the operator.
+func validatePodScheduling(spec spinv1alpha1.SpinAppSpec, executor *spinv1alpha1.SpinAppExecutor) *field.Error {
+	// The executor is validated separately
+	if executor == nil || executor.Spec.CreateDeployment {
+		return nil
+	}
+
+	if !equality.Semantic.DeepEqual(spec.PodScheduling, spinv1alpha1.PodScheduling{}) {
+		return field.Forbidden(field.NewPath("spec"),
+			"nodeSelector, tolerations, affinity, topologySpreadConstraints and priorityClassName can't be set when the executor does not use operator deployments")
+	}
+
+	return nil
+}
+
+// validateAppRolloutControls validates the rollout controls of a SpinApp
+// together with the defaults of its executor.
+func validateAppRolloutControls(spec spinv1alpha1.SpinAppSpec, executor *spinv1alpha1.SpinAppExecutor) *field.Error {
+	// The executor is validated separately
+	if executor == nil {
+		return validateRolloutControls(field.NewPath("spec"), spec.RolloutControls, spinv1alpha1.RolloutControls{})
+	}
+
+	if !executor.Spec.CreateDeployment {
+		if !equality.Semantic.DeepEqual(spec.RolloutControls, spinv1alpha1.RolloutControls{}) {
+			return field.Forbidden(field.NewPath("spec"),
+				"strategy, minReadySeconds, progressDeadlineSeconds and revisionHistoryLimit can't be set when the executor does not use operator deployments")
+		}
+		return nil
+	}
+
+	var defaults spinv1alpha1.RolloutControls
+	if executor.Spec.DeploymentConfig != nil {
+		defaults = executor.Spec.DeploymentConfig.RolloutControls
+	}
+	return validateRolloutControls(field.NewPath("spec"), spec.RolloutControls, defaults)
+}
+
+// validateRolloutControls validates rollout controls, merged onto defaults,
+// against the rules the Deployment API enforces, so that invalid values are
+// rejected up front instead of failing every reconcile.
+func validateRolloutControls(path *field.Path, controls, defaults spinv1alpha1.RolloutControls) *field.Error {
+	if strategy := controls.Strategy; strategy != nil {
+		strategyPath := path.Child("strategy")
+		if strategy.Type == appsv1.RecreateDeploymentStrategyType && strategy.RollingUpdate != nil {
+			return field.Forbidden(strategyPath.Child("rollingUpdate"), "rollingUpdate can't be set when type is Recreate")
+		}
+
+		if rollingUpdate := strategy.RollingUpdate; rollingUpdate != nil {
+			// Percentages depend on the replica count, so only values that
+			// are zero for any replica count are rejected.
+			isZero := func(value *intstr.IntOrString, roundUp bool) bool {
+				if value == nil {
+					return false
+				}
+				scaled, err := intstr.GetScaledValueFromIntOrPercent(value, 100, roundUp)
+				return err == nil && scaled == 0
+			}
+			if isZero(rollingUpdate.MaxSurge, true) && isZero(rollingUpdate.MaxUnavailable, true) {
+				return field.Invalid(strategyPath.Child("rollingUpdate").Child("maxUnavailable"), rollingUpdate.MaxUnavailable.String(),
+					"maxUnavailable and maxSurge can't both be 0")
+			}
+		}
+	}
+
+	minReadySeconds := int32(0)
+	if defaults.MinReadySeconds != nil {
+		minReadySeconds = *defaults.MinReadySeconds
+	}
+	if controls.MinReadySeconds != nil {
+		minReadySeconds = *controls.MinReadySeconds
+	}
+	progressDeadlineSeconds := int32(600)
+	if defaults.ProgressDeadlineSeconds != nil {
+		progressDeadlineSeconds = *defaults.ProgressDeadlineSeconds
+	}
+	if controls.ProgressDeadlineSeconds != nil {
+		progressDeadlineSeconds = *controls.ProgressDeadlineSeconds
+	}
+	if progressDeadlineSeconds <= minReadySeconds {
+		return field.Invalid(path.Child("progressDeadlineSeconds"), progressDeadlineSeconds,
+			fmt.Sprintf("progressDeadlineSeconds must be greater than minReadySeconds (%d)", minReadySeconds))
+	}
+
+	return nil
+}
+
+// validateRollout validates the progressive rollout configuration of a
+// SpinApp.
+func validateRollout(spec spinv1alpha1.SpinAppSpec, executor *spinv1alpha1.SpinAppExecutor) *field.Error {
+	rollout := spec.Rollout
+	if rollout == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec").Child("rollout")
+
+	// The executor is validated separately
+	if executor != nil && !executor.Spec.CreateDeployment {
+		return field.Forbidden(path, "rollout can't be set when the executor does not use operator deployments")
+	}
+
+	// The replicas of the stable and canary Deployments are set by the
+	// rollout, which would fight with an autoscaler.
+	if spec.EnableAutoscaling {
+		return field.Forbidden(path, "rollout can't be used together with enableAutoscaling")
+	}
+
+	if (rollout.Canary == nil) == (rollout.BlueGreen == nil) {
+		return field.Invalid(path, rollout, "exactly one of canary or blueGreen must be set")
+	}
+
+	if rollout.Canary != nil {
+		stepsPath := path.Child("canary").Child("steps")
+		if len(rollout.Canary.Steps) == 0 {
+			return field.Required(stepsPath, "at least one step must be set")
+		}
+		for i, step := range rollout.Canary.Steps {
+			if step.Weight < 1 || step.Weight > 100 {
+				return field.Invalid(stepsPath.Index(i).Child("weight"), step.Weight, "weight must be between 1 and 100")
+			}
+			if step.Pause != nil && step.Pause.Duration < 0 {
+				return field.Invalid(stepsPath.Index(i).Child("pause"), step.Pause.Duration.String(), "pause must not be negative")
+			}
+		}
+	}
+
+	if rollout.BlueGreen != nil && rollout.BlueGreen.PromotionPause != nil && rollout.BlueGreen.PromotionPause.Duration < 0 {
+		return field.Invalid(path.Child("blueGreen").Child("promotionPause"), rollout.BlueGreen.PromotionPause.Duration.String(),
+			"promotionPause must not be negative")
+	}
+
+	return nil
+}
+
+// validateSuspend validates that a SpinApp is only suspended when the operator
+// manages its deployment. Suspending autoscaled apps is allowed, the operator
+// removes their autoscaler while they are suspended.
+func validateSuspend(spec spinv1alpha1.SpinAppSpec, executor *spinv1alpha1.SpinAppExecutor) *field.Error {
+	// The executor is validated separately
+	if spec.Suspend && executor != nil && !executor.Spec.CreateDeployment {
+		return field.Forbidden(field.NewPath("spec").Child("suspend"),
+			"suspend can't be set when the executor does not use operator deployments")
+	}
+
+	return nil
+}
+
+// validateDisruptionBudget validates that the disruption budget of a SpinApp
+// allows at least one of its pods to be evicted, so that it doesn't block node
+// drains.
+func validateDisruptionBudget(spec spinv1alpha1.SpinAppSpec, executor *spinv1alpha1.SpinAppExecutor) *field.Error {
+	budget := spec.DisruptionBudget
+	if budget == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec").Child("disruptionBudget")
+
+	// The executor is validated separately
+	if executor != nil && !executor.Spec.CreateDeployment {
+		return field.Forbidden(path, "disruptionBudget can't be set when the executor does not use operator deployments")
+	}
+
+	if (budget.MinAvailable == nil) == (budget.MaxUnavailable == nil) {
+		return field.Invalid(path, budget, "exactly one of minAvailable or maxUnavailable must be set")
+	}
+
+	if budget.MaxUnavailable != nil {
+		// Percentages are rounded up, so any positive one allows an eviction.
+		maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(budget.MaxUnavailable, 100, true)
+		if err != nil {
+			return field.Invalid(path.Child("maxUnavailable"), budget.MaxUnavailable.String(), err.Error())
+		}
+		if maxUnavailable <= 0 || maxUnavailable > 100 && budget.MaxUnavailable.Type == intstr.String {
+			return field.Invalid(path.Child("maxUnavailable"), budget.MaxUnavailable.String(),
+				"maxUnavailable must be a positive number or a percentage between 1% and 100%")
+		}
+		return nil
+	}
+
+	minAvailablePath := path.Child("minAvailable")
+	if _, err := intstr.GetScaledValueFromIntOrPercent(budget.MinAvailable, 100, true); err != nil {
+		return field.Invalid(minAvailablePath, budget.MinAvailable.String(), err.Error())
+	}
+
+	// The replica count of apps scaled by an autoscaler that isn't managed by
+	// the operator is unknown, and apps scaled to zero have nothing to evict.
+	replicas := spec.Replicas
+	if spec.EnableAutoscaling {
+		replicas = 0
+		if spec.Autoscaling != nil {
+			replicas = 1
+			if spec.Autoscaling.MinReplicas != nil {
+				replicas = *spec.Autoscaling.MinReplicas
+			}
+		}
+	}
+	if replicas == 0 {
+		return nil
+	}
+
+	// The disruption controller rounds percentages of minAvailable up.
+	minAvailable, err := intstr.GetScaledValueFromIntOrPercent(budget.MinAvailable, int(replicas), true)
+	if err != nil {
+		return field.Invalid(minAvailablePath, budget.MinAvailable.String(), err.Error())
+	}
+	if minAvailable >= int(replicas) {
+		return field.Invalid(minAvailablePath, budget.MinAvailable.String(),
+			fmt.Sprintf("minAvailable must leave at least one of the app's %d replica(s) evictable", replicas))
+	}
+
+	return nil
+}
+
+// validateService validates the Service configuration of a SpinApp. oldSpec is
+// nil on create.
+func validateService(spec spinv1alpha1.SpinAppSpec, oldSpec *spinv1alpha1.SpinAppSpec) *field.Error {
+	service := spec.Service
+	if service == nil {
+		service = &spinv1alpha1.ServiceConfig{}
+	}
+
+	path := field.NewPath("spec").Child("service")
+
+	if service.Skip {
+		if spec.Exposure != nil && (spec.Exposure.Ingress != nil || spec.Exposure.HTTPRoute != nil) {
+			return field.Forbidden(field.NewPath("spec").Child("exposure"), "the app can't be exposed when service.skip is true")
+		}
+		return nil
+	}
+
+	serviceType := service.Type
+	if serviceType == "" {
+		serviceType = corev1.ServiceTypeClusterIP
+	}
+	isExternal := serviceType == corev1.ServiceTypeNodePort || serviceType == corev1.ServiceTypeLoadBalancer
+
+	if service.Headless && serviceType != corev1.ServiceTypeClusterIP {
+		return field.Invalid(path.Child("headless"), service.Headless, "headless services must be of type ClusterIP")
+	}
+	// The cluster IP of a Service is immutable
+	if oldSpec != nil {
+		oldService := oldSpec.Service
+		if oldService == nil {
+			oldService = &spinv1alpha1.ServiceConfig{}
+		}
+		if !oldService.Skip && oldService.Headless != service.Headless {
+			return field.Forbidden(path.Child("headless"), "headless can't be changed once the service exists")
+		}
+	}
+	if service.NodePort != nil && !isExternal {
+		return field.Invalid(path.Child("nodePort"), *service.NodePort, "nodePort can only be set for services of type NodePort or LoadBalancer")
+	}
+	if service.ExternalTrafficPolicy != "" && !isExternal {
+		return field.Invalid(path.Child("externalTrafficPolicy"), service.ExternalTrafficPolicy,
+			"externalTrafficPolicy can only be set for services of type NodePort or LoadBalancer")
+	}
+	if len(service.LoadBalancerSourceRanges) > 0 && serviceType != corev1.ServiceTypeLoadBalancer {
+		return field.Invalid(path.Child("loadBalancerSourceRanges"), service.LoadBalancerSourceRanges,
+			"loadBalancerSourceRanges can only be set for services of type LoadBalancer")
+	}
+	for i, cidr := range service.LoadBalancerSourceRanges {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return field.Invalid(path.Child("loadBalancerSourceRanges").Index(i), cidr, "must be a valid CIDR, e.g 10.0.0.0/8")
+		}
+	}
+	if len(service.IPFamilies) == 2 && service.IPFamilies[0] == service.IPFamilies[1] {
+		return field.Duplicate(path.Child("ipFamilies").Index(1), service.IPFamilies[1])
+	}
+
+	return nil
+}
+
+// validateExposure validates the hosts and paths of the Ingress and HTTPRoute
+// that expose a SpinApp.
+func validateExposure(spec spinv1alpha1.SpinAppSpec) *field.Error {
+	exposure := spec.Exposure
+	if exposure == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec").Child("exposure")
+
+	if ingress := exposure.Ingress; ingress != nil {
+		ingressPath := path.Child("ingress")
+		if err := validateHostname(ingressPath.Child("host"), ingress.Host); err != nil {
+			return err
+		}
+		for i, p := range ingress.Paths {
+			if !strings.HasPrefix(p.Path, "/") {
+				return field.Invalid(ingressPath.Child("paths").Index(i).Child("path"), p.Path, "path must begin with '/'")
+			}
+		}
+	}
+
+	if route := exposure.HTTPRoute; route != nil {
+		routePath := path.Child("httpRoute")
+		for i, hostname := range route.Hostnames {
+			if err := validateHostname(routePath.Child("hostnames").Index(i), hostname); err != nil {
+				return err
+			}
+		}
+		for i, p := range route.Paths {
+			// Regular expressions are implementation specific
+			if p.Type != "RegularExpression" && !strings.HasPrefix(p.Value, "/") {
+				return field.Invalid(routePath.Child("paths").Index(i).Child("value"), p.Value, "path must begin with '/'")
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateHostname validates a DNS hostname, which may have a wildcard prefix.
+func validateHostname(path *field.Path, hostname string) *field.Error {
+	var errs []string
+	if strings.HasPrefix(hostname, "*.") {
+		errs = validation.IsWildcardDNS1123Subdomain(hostname)
+	} else {
+		errs = validation.IsDNS1123Subdomain(hostname)
+	}
+	if len(errs) > 0 {
+		return field.Invalid(path, hostname, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// securityContextWarnings returns a warning for every security context field
+// of a SpinApp that weakens the secure defaults of its executor, e.g allowing
+// privilege escalation or running as root.
+func securityContextWarnings(spec spinv1alpha1.SpinAppSpec, executor *spinv1alpha1.SpinAppExecutor) admission.Warnings {
+	if executor == nil || executor.Spec.DeploymentConfig == nil {
+		return nil
+	}
+	config := executor.Spec.DeploymentConfig
+
+	var warnings admission.Warnings
+	weakens := func(path *field.Path) {
+		warnings = append(warnings, fmt.Sprintf("%s weakens the security defaults of executor %q", path, executor.Name))
+	}
+
+	if defaults, app := config.PodSecurityContext, spec.PodSecurityContext; defaults != nil && app != nil {
+		path := field.NewPath("spec").Child("podSecurityContext")
+		if weakensRunAsNonRoot(defaults.RunAsNonRoot, app.RunAsNonRoot, app.RunAsUser) {
+			weakens(path.Child("runAsNonRoot"))
+		}
+		if weakensSeccompProfile(defaults.SeccompProfile, app.SeccompProfile) {
+			weakens(path.Child("seccompProfile"))
+		}
+	}
+
+	if defaults, app := config.SecurityContext, spec.SecurityContext; defaults != nil && app != nil {
+		path := field.NewPath("spec").Child("securityContext")
+		if weakensRunAsNonRoot(defaults.RunAsNonRoot, app.RunAsNonRoot, app.RunAsUser) {
+			weakens(path.Child("runAsNonRoot"))
+		}
+		if isTrue(app.Privileged) && !isTrue(defaults.Privileged) {
+			weakens(path.Child("privileged"))
+		}
+		if isFalse(defaults.AllowPrivilegeEscalation) && isTrue(app.AllowPrivilegeEscalation) {
+			weakens(path.Child("allowPrivilegeEscalation"))
+		}
+		if isTrue(defaults.ReadOnlyRootFilesystem) && isFalse(app.ReadOnlyRootFilesystem) {
+			weakens(path.Child("readOnlyRootFilesystem"))
+		}
+		if weakensCapabilities(defaults.Capabilities, app.Capabilities) {
+			weakens(path.Child("capabilities"))
+		}
+		if weakensSeccompProfile(defaults.SeccompProfile, app.SeccompProfile) {
+			weakens(path.Child("seccompProfile"))
+		}
+	}
+
+	return warnings
+}
+
+// weakensRunAsNonRoot returns whether an app allows running as root when its
+// executor requires running as non-root.
+func weakensRunAsNonRoot(defaultRunAsNonRoot, runAsNonRoot *bool, runAsUser *int64) bool {
+	if !isTrue(defaultRunAsNonRoot) {
+		return false
+	}
+	return isFalse(runAsNonRoot) || (runAsUser != nil && *runAsUser == 0)
+}
+
+// weakensSeccompProfile returns whether an app disables the seccomp profile of
+// its executor.
+func weakensSeccompProfile(defaults, profile *corev1.SeccompProfile) bool {
+	if defaults == nil || defaults.Type == corev1.SeccompProfileTypeUnconfined {
+		return false
+	}
+	return profile != nil && profile.Type == corev1.SeccompProfileTypeUnconfined
+}
+
+// weakensCapabilities returns whether an app adds capabilities its executor
+// doesn't, or stops dropping all capabilities when its executor does.
+func weakensCapabilities(defaults, capabilities *corev1.Capabilities) bool {
+	if capabilities == nil {
+		return false
+	}
+	if defaults == nil {
+		defaults = &corev1.Capabilities{}
+	}
+
+	for _, capability := range capabilities.Add {
+		if !slices.Contains(defaults.Add, capability) {
+			return true
+		}
+	}
+	return slices.Contains(defaults.Drop, "ALL") && !slices.Contains(capabilities.Drop, "ALL")
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+func isFalse(b *bool) bool {
+	return b != nil && !*b
+}
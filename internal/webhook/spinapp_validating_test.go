@@ -1,12 +1,25 @@
 package webhook
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
 	"github.com/spinkube/spin-operator/internal/constants"
+	"github.com/spinkube/spin-operator/internal/generics"
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 func TestValidateExecutor(t *testing.T) {
@@ -18,20 +31,108 @@ func TestValidateExecutor(t *testing.T) {
 	_, fldErr = validateExecutor(
 		spinv1alpha1.SpinAppSpec{Executor: constants.CyclotronExecutor},
 		func(string) (*spinv1alpha1.SpinAppExecutor, error) { return nil, errors.New("executor not found?") })
-	require.EqualError(t, fldErr, "spec.executor: Invalid value: \"cyclotron\": executor does not exist in namespace")
+	require.EqualError(t, fldErr, "spec.executor: Invalid value: \"cyclotron\": executor does not exist in namespace or cluster")
 
 	_, fldErr = validateExecutor(spinv1alpha1.SpinAppSpec{Executor: constants.ContainerDShimSpinExecutor}, func(string) (*spinv1alpha1.SpinAppExecutor, error) { return nil, nil })
 	require.Nil(t, fldErr)
 }
 
+func TestFetchExecutor(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, spinv1alpha1.AddToScheme(scheme))
+
+	validator := &SpinAppValidator{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&spinv1alpha1.SpinAppExecutor{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "default"},
+			Spec:       spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true},
+		},
+		&spinv1alpha1.ClusterSpinAppExecutor{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared"},
+			Spec:       spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: false},
+		},
+		&spinv1alpha1.ClusterSpinAppExecutor{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-only"},
+			Spec:       spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true},
+		},
+	).Build()}
+
+	// Namespaced executors take precedence over cluster executors
+	executor, err := validator.fetchExecutor(context.Background(), "default")("shared")
+	require.NoError(t, err)
+	require.True(t, executor.Spec.CreateDeployment)
+
+	// Cluster executors are used when there's no namespaced executor
+	executor, err = validator.fetchExecutor(context.Background(), "another")("shared")
+	require.NoError(t, err)
+	require.False(t, executor.Spec.CreateDeployment)
+
+	executor, err = validator.fetchExecutor(context.Background(), "default")("cluster-only")
+	require.NoError(t, err)
+	require.Equal(t, "cluster-only", executor.Name)
+
+	_, err = validator.fetchExecutor(context.Background(), "default")("missing")
+	require.True(t, apierrors.IsNotFound(err))
+}
+
 func TestValidateReplicas(t *testing.T) {
 	t.Parallel()
 
-	fldErr := validateReplicas(spinv1alpha1.SpinAppSpec{})
+	fldErr := validateReplicas(spinv1alpha1.SpinAppSpec{}, nil)
 	require.EqualError(t, fldErr, "spec.replicas: Invalid value: 0: replicas must be > 0")
 
-	fldErr = validateReplicas(spinv1alpha1.SpinAppSpec{Replicas: 1})
+	fldErr = validateReplicas(spinv1alpha1.SpinAppSpec{Replicas: 1}, nil)
+	require.Nil(t, fldErr)
+
+	fldErr = validateReplicas(spinv1alpha1.SpinAppSpec{EnableAutoscaling: true}, nil)
+	require.Nil(t, fldErr)
+
+	fldErr = validateReplicas(spinv1alpha1.SpinAppSpec{EnableAutoscaling: true, Replicas: 2}, nil)
+	require.EqualError(t, fldErr, "spec.replicas: Invalid value: 2: replicas cannot be set when autoscaling is enabled")
+
+	// Replicas set by an autoscaler through the scale subresource are kept on update
+	fldErr = validateReplicas(spinv1alpha1.SpinAppSpec{EnableAutoscaling: true, Replicas: 3},
+		&spinv1alpha1.SpinAppSpec{EnableAutoscaling: true, Replicas: 3})
+	require.Nil(t, fldErr)
+
+	fldErr = validateReplicas(spinv1alpha1.SpinAppSpec{EnableAutoscaling: true, Replicas: 5},
+		&spinv1alpha1.SpinAppSpec{EnableAutoscaling: true, Replicas: 3})
+	require.EqualError(t, fldErr, "spec.replicas: Invalid value: 5: replicas cannot be set when autoscaling is enabled")
+
+	// Apps whose autoscaler targets the SpinApp set the replica count to start from
+	fldErr = validateReplicas(spinv1alpha1.SpinAppSpec{
+		EnableAutoscaling: true,
+		AutoscalerTarget:  spinv1alpha1.AutoscalerTargetSpinApp,
+		Replicas:          2,
+	}, nil)
+	require.Nil(t, fldErr)
+}
+
+func TestValidateAutoscalerTarget(t *testing.T) {
+	t.Parallel()
+
+	fldErr := validateAutoscalerTarget(spinv1alpha1.SpinAppSpec{Replicas: 1})
+	require.Nil(t, fldErr)
+
+	fldErr = validateAutoscalerTarget(spinv1alpha1.SpinAppSpec{
+		EnableAutoscaling: true,
+		AutoscalerTarget:  spinv1alpha1.AutoscalerTargetSpinApp,
+	})
 	require.Nil(t, fldErr)
+
+	fldErr = validateAutoscalerTarget(spinv1alpha1.SpinAppSpec{
+		Replicas:         1,
+		AutoscalerTarget: spinv1alpha1.AutoscalerTargetSpinApp,
+	})
+	require.EqualError(t, fldErr, "spec.autoscalerTarget: Forbidden: autoscalerTarget can't be set unless enableAutoscaling is true")
+
+	fldErr = validateAutoscalerTarget(spinv1alpha1.SpinAppSpec{
+		EnableAutoscaling: true,
+		AutoscalerTarget:  spinv1alpha1.AutoscalerTargetDeployment,
+		Autoscaling:       &spinv1alpha1.Autoscaling{MaxReplicas: 3},
+	})
+	require.EqualError(t, fldErr, "spec.autoscalerTarget: Forbidden: autoscalerTarget can't be set together with autoscaling, which always targets the SpinApp")
 }
 
 func TestValidateAnnotations(t *testing.T) {
@@ -75,3 +176,462 @@ func TestValidateAnnotations(t *testing.T) {
 	}, deploymentlessExecutor)
 	require.Nil(t, fldErr)
 }
+
+func TestValidateAutoscaling(t *testing.T) {
+	t.Parallel()
+
+	deploymentlessExecutor := &spinv1alpha1.SpinAppExecutor{
+		Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: false},
+	}
+	deploymentfullExecutor := &spinv1alpha1.SpinAppExecutor{
+		Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true},
+	}
+
+	validSpec := func() spinv1alpha1.SpinAppSpec {
+		return spinv1alpha1.SpinAppSpec{
+			EnableAutoscaling: true,
+			Autoscaling: &spinv1alpha1.Autoscaling{
+				MinReplicas:                    generics.Ptr(int32(2)),
+				MaxReplicas:                    10,
+				TargetCPUUtilizationPercentage: generics.Ptr(int32(50)),
+			},
+			Resources: spinv1alpha1.Resources{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+			},
+		}
+	}
+
+	require.Nil(t, validateAutoscaling(spinv1alpha1.SpinAppSpec{}, deploymentfullExecutor))
+	require.Nil(t, validateAutoscaling(validSpec(), deploymentfullExecutor))
+
+	spec := validSpec()
+	spec.EnableAutoscaling = false
+	require.EqualError(t, validateAutoscaling(spec, deploymentfullExecutor),
+		"spec.autoscaling: Forbidden: autoscaling can't be configured unless enableAutoscaling is true")
+
+	require.EqualError(t, validateAutoscaling(validSpec(), deploymentlessExecutor),
+		"spec.autoscaling: Forbidden: autoscaling can't be configured when the executor does not use operator deployments")
+
+	spec = validSpec()
+	spec.Autoscaling.MinReplicas = generics.Ptr(int32(11))
+	require.EqualError(t, validateAutoscaling(spec, deploymentfullExecutor),
+		"spec.autoscaling.minReplicas: Invalid value: 11: minReplicas must be <= maxReplicas")
+
+	spec = validSpec()
+	spec.Autoscaling.TargetCPUUtilizationPercentage = nil
+	require.EqualError(t, validateAutoscaling(spec, deploymentfullExecutor),
+		"spec.autoscaling: Required value: at least one of targetCPUUtilizationPercentage or targetMemoryUtilizationPercentage must be set")
+
+	spec = validSpec()
+	spec.Resources.Requests = nil
+	require.EqualError(t, validateAutoscaling(spec, deploymentfullExecutor),
+		"spec.resources.requests[cpu]: Required value: cpu requests must be set when targetCPUUtilizationPercentage is used")
+
+	// Requests default to limits
+	spec.Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}
+	require.Nil(t, validateAutoscaling(spec, deploymentfullExecutor))
+
+	spec = validSpec()
+	spec.Autoscaling.TargetMemoryUtilizationPercentage = generics.Ptr(int32(80))
+	require.EqualError(t, validateAutoscaling(spec, deploymentfullExecutor),
+		"spec.resources.requests[memory]: Required value: memory requests must be set when targetMemoryUtilizationPercentage is used")
+
+	// Scaling to zero requires KEDA
+	spec = validSpec()
+	spec.Autoscaling.MinReplicas = generics.Ptr(int32(0))
+	require.EqualError(t, validateAutoscaling(spec, deploymentfullExecutor),
+		"spec.autoscaling.minReplicas: Invalid value: 0: minReplicas can only be 0 when scaling with keda")
+
+	spec.Autoscaling.KEDA = &spinv1alpha1.KEDAAutoscaling{}
+	require.Nil(t, validateAutoscaling(spec, deploymentfullExecutor))
+
+	// KEDA triggers can be used instead of utilization targets
+	spec.Autoscaling.TargetCPUUtilizationPercentage = nil
+	require.EqualError(t, validateAutoscaling(spec, deploymentfullExecutor),
+		"spec.autoscaling.keda.triggers: Required value: at least one trigger, targetCPUUtilizationPercentage or targetMemoryUtilizationPercentage must be set")
+
+	spec.Autoscaling.KEDA.Triggers = []spinv1alpha1.KEDATrigger{{
+		Type:     "redis",
+		Metadata: map[string]string{"address": "redis:6379", "listName": "orders", "listLength": "5"},
+	}}
+	require.Nil(t, validateAutoscaling(spec, deploymentfullExecutor))
+
+	spec = validSpec()
+	spec.Autoscaling.ScaleDownStabilizationWindowSeconds = generics.Ptr(int32(60))
+	spec.Autoscaling.Behavior = &autoscalingv2.HorizontalPodAutoscalerBehavior{
+		ScaleDown: &autoscalingv2.HPAScalingRules{StabilizationWindowSeconds: generics.Ptr(int32(120))},
+	}
+	require.EqualError(t, validateAutoscaling(spec, deploymentfullExecutor),
+		"spec.autoscaling.scaleDownStabilizationWindowSeconds: Invalid value: 60: "+
+			"scaleDownStabilizationWindowSeconds and behavior.scaleDown.stabilizationWindowSeconds are mutually exclusive")
+}
+
+func TestValidateExposure(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, validateExposure(spinv1alpha1.SpinAppSpec{}))
+
+	validSpec := func() spinv1alpha1.SpinAppSpec {
+		return spinv1alpha1.SpinAppSpec{
+			Exposure: &spinv1alpha1.Exposure{
+				Ingress: &spinv1alpha1.IngressExposure{
+					Host:  "app.example.com",
+					Paths: []spinv1alpha1.IngressPath{{Path: "/api"}},
+				},
+				HTTPRoute: &spinv1alpha1.HTTPRouteExposure{
+					ParentRefs: []spinv1alpha1.GatewayParentRef{{Name: "gateway"}},
+					Hostnames:  []string{"*.example.com"},
+					Paths:      []spinv1alpha1.HTTPRoutePath{{Value: "/api"}, {Value: "^/v[0-9]+/", Type: "RegularExpression"}},
+				},
+			},
+		}
+	}
+	require.Nil(t, validateExposure(validSpec()))
+
+	spec := validSpec()
+	spec.Exposure.Ingress.Host = "Not_A_Host"
+	require.ErrorContains(t, validateExposure(spec), `spec.exposure.ingress.host: Invalid value: "Not_A_Host"`)
+
+	spec = validSpec()
+	spec.Exposure.Ingress.Paths[0].Path = "api"
+	require.EqualError(t, validateExposure(spec),
+		`spec.exposure.ingress.paths[0].path: Invalid value: "api": path must begin with '/'`)
+
+	spec = validSpec()
+	spec.Exposure.HTTPRoute.Hostnames = []string{"app.example.com", "*"}
+	require.ErrorContains(t, validateExposure(spec), `spec.exposure.httpRoute.hostnames[1]: Invalid value: "*"`)
+
+	spec = validSpec()
+	spec.Exposure.HTTPRoute.Paths[0].Type = "Exact"
+	spec.Exposure.HTTPRoute.Paths[0].Value = "api"
+	require.EqualError(t, validateExposure(spec),
+		`spec.exposure.httpRoute.paths[0].value: Invalid value: "api": path must begin with '/'`)
+}
+
+func TestValidateService(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, validateService(spinv1alpha1.SpinAppSpec{}, nil))
+
+	spec := spinv1alpha1.SpinAppSpec{
+		Service: &spinv1alpha1.ServiceConfig{
+			Type:                     corev1.ServiceTypeLoadBalancer,
+			Port:                     generics.Ptr(int32(8080)),
+			NodePort:                 generics.Ptr(int32(30080)),
+			ExternalTrafficPolicy:    corev1.ServiceExternalTrafficPolicyLocal,
+			LoadBalancerSourceRanges: []string{"10.0.0.0/8", "2001:db8::/32"},
+			IPFamilies:               []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol},
+		},
+	}
+	require.Nil(t, validateService(spec, nil))
+
+	spec.Service.LoadBalancerSourceRanges = []string{"10.0.0.1"}
+	require.EqualError(t, validateService(spec, nil),
+		`spec.service.loadBalancerSourceRanges[0]: Invalid value: "10.0.0.1": must be a valid CIDR, e.g 10.0.0.0/8`)
+
+	spec.Service.LoadBalancerSourceRanges = nil
+	spec.Service.IPFamilies = []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv6Protocol}
+	require.EqualError(t, validateService(spec, nil), `spec.service.ipFamilies[1]: Duplicate value: "IPv6"`)
+
+	// External traffic options need an externally reachable service
+	spec.Service = &spinv1alpha1.ServiceConfig{NodePort: generics.Ptr(int32(30080))}
+	require.EqualError(t, validateService(spec, nil),
+		"spec.service.nodePort: Invalid value: 30080: nodePort can only be set for services of type NodePort or LoadBalancer")
+
+	spec.Service = &spinv1alpha1.ServiceConfig{Type: corev1.ServiceTypeNodePort, LoadBalancerSourceRanges: []string{"10.0.0.0/8"}}
+	require.ErrorContains(t, validateService(spec, nil),
+		"loadBalancerSourceRanges can only be set for services of type LoadBalancer")
+
+	// Headless services
+	spec.Service = &spinv1alpha1.ServiceConfig{Type: corev1.ServiceTypeNodePort, Headless: true}
+	require.EqualError(t, validateService(spec, nil),
+		"spec.service.headless: Invalid value: true: headless services must be of type ClusterIP")
+
+	spec.Service = &spinv1alpha1.ServiceConfig{Headless: true}
+	require.Nil(t, validateService(spec, nil))
+	require.Nil(t, validateService(spec, &spinv1alpha1.SpinAppSpec{Service: &spinv1alpha1.ServiceConfig{Headless: true}}))
+	require.EqualError(t, validateService(spec, &spinv1alpha1.SpinAppSpec{}),
+		"spec.service.headless: Forbidden: headless can't be changed once the service exists")
+	require.EqualError(t, validateService(spinv1alpha1.SpinAppSpec{}, &spinv1alpha1.SpinAppSpec{Service: &spinv1alpha1.ServiceConfig{Headless: true}}),
+		"spec.service.headless: Forbidden: headless can't be changed once the service exists")
+	// There's no service to change when it was skipped
+	require.Nil(t, validateService(spec, &spinv1alpha1.SpinAppSpec{Service: &spinv1alpha1.ServiceConfig{Skip: true}}))
+
+	// Skipped services can't be exposed
+	spec.Service = &spinv1alpha1.ServiceConfig{Skip: true}
+	require.Nil(t, validateService(spec, nil))
+	spec.Exposure = &spinv1alpha1.Exposure{Ingress: &spinv1alpha1.IngressExposure{Host: "app.example.com"}}
+	require.EqualError(t, validateService(spec, nil),
+		"spec.exposure: Forbidden: the app can't be exposed when service.skip is true")
+}
+
+func TestValidatePodScheduling(t *testing.T) {
+	t.Parallel()
+
+	deploymentlessExecutor := &spinv1alpha1.SpinAppExecutor{
+		Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: false},
+	}
+	deploymentfullExecutor := &spinv1alpha1.SpinAppExecutor{
+		Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true},
+	}
+
+	spec := spinv1alpha1.SpinAppSpec{}
+	require.Nil(t, validatePodScheduling(spec, deploymentlessExecutor))
+
+	spec.Tolerations = []corev1.Toleration{{Key: "spinkube.dev/shim", Operator: corev1.TolerationOpExists}}
+	require.Nil(t, validatePodScheduling(spec, deploymentfullExecutor))
+	require.EqualError(t, validatePodScheduling(spec, deploymentlessExecutor),
+		"spec: Forbidden: nodeSelector, tolerations, affinity, topologySpreadConstraints and priorityClassName can't be set when the executor does not use operator deployments")
+}
+
+func TestValidateAppRolloutControls(t *testing.T) {
+	t.Parallel()
+
+	deploymentlessExecutor := &spinv1alpha1.SpinAppExecutor{
+		Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: false},
+	}
+	executor := &spinv1alpha1.SpinAppExecutor{
+		Spec: spinv1alpha1.SpinAppExecutorSpec{
+			CreateDeployment: true,
+			DeploymentConfig: &spinv1alpha1.ExecutorDeploymentConfig{
+				RolloutControls: spinv1alpha1.RolloutControls{MinReadySeconds: generics.Ptr(int32(30))},
+			},
+		},
+	}
+
+	spec := spinv1alpha1.SpinAppSpec{}
+	require.Nil(t, validateAppRolloutControls(spec, deploymentlessExecutor))
+	require.Nil(t, validateAppRolloutControls(spec, executor))
+
+	spec.RevisionHistoryLimit = generics.Ptr(int32(2))
+	require.EqualError(t, validateAppRolloutControls(spec, deploymentlessExecutor),
+		"spec: Forbidden: strategy, minReadySeconds, progressDeadlineSeconds and revisionHistoryLimit can't be set when the executor does not use operator deployments")
+
+	// The progress deadline is compared with the minReadySeconds of the executor
+	spec.ProgressDeadlineSeconds = generics.Ptr(int32(30))
+	require.EqualError(t, validateAppRolloutControls(spec, executor),
+		"spec.progressDeadlineSeconds: Invalid value: 30: progressDeadlineSeconds must be greater than minReadySeconds (30)")
+	spec.MinReadySeconds = generics.Ptr(int32(5))
+	require.Nil(t, validateAppRolloutControls(spec, executor))
+
+	spec.Strategy = &appsv1.DeploymentStrategy{
+		Type:          appsv1.RecreateDeploymentStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDeployment{},
+	}
+	require.EqualError(t, validateAppRolloutControls(spec, executor),
+		"spec.strategy.rollingUpdate: Forbidden: rollingUpdate can't be set when type is Recreate")
+
+	spec.Strategy = &appsv1.DeploymentStrategy{
+		Type: appsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDeployment{
+			MaxSurge:       generics.Ptr(intstr.FromString("0%")),
+			MaxUnavailable: generics.Ptr(intstr.FromInt32(0)),
+		},
+	}
+	require.EqualError(t, validateAppRolloutControls(spec, executor),
+		`spec.strategy.rollingUpdate.maxUnavailable: Invalid value: "0": maxUnavailable and maxSurge can't both be 0`)
+	spec.Strategy.RollingUpdate.MaxSurge = generics.Ptr(intstr.FromString("1%"))
+	require.Nil(t, validateAppRolloutControls(spec, executor))
+}
+
+func TestValidateRollout(t *testing.T) {
+	t.Parallel()
+
+	deploymentlessExecutor := &spinv1alpha1.SpinAppExecutor{
+		Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: false},
+	}
+	deploymentfullExecutor := &spinv1alpha1.SpinAppExecutor{
+		Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true},
+	}
+
+	spec := spinv1alpha1.SpinAppSpec{Replicas: 2}
+	require.Nil(t, validateRollout(spec, deploymentlessExecutor))
+
+	spec.Rollout = &spinv1alpha1.ProgressiveRollout{
+		Canary: &spinv1alpha1.CanaryRollout{Steps: []spinv1alpha1.CanaryStep{{Weight: 20}, {Weight: 50}}},
+	}
+	require.Nil(t, validateRollout(spec, deploymentfullExecutor))
+	require.EqualError(t, validateRollout(spec, deploymentlessExecutor),
+		"spec.rollout: Forbidden: rollout can't be set when the executor does not use operator deployments")
+
+	spec.EnableAutoscaling = true
+	require.EqualError(t, validateRollout(spec, deploymentfullExecutor),
+		"spec.rollout: Forbidden: rollout can't be used together with enableAutoscaling")
+	spec.EnableAutoscaling = false
+
+	spec.Rollout.BlueGreen = &spinv1alpha1.BlueGreenRollout{}
+	require.ErrorContains(t, validateRollout(spec, deploymentfullExecutor), "exactly one of canary or blueGreen must be set")
+	spec.Rollout.Canary = nil
+	require.Nil(t, validateRollout(spec, deploymentfullExecutor))
+
+	spec.Rollout.BlueGreen.PromotionPause = &metav1.Duration{Duration: -time.Second}
+	require.EqualError(t, validateRollout(spec, deploymentfullExecutor),
+		`spec.rollout.blueGreen.promotionPause: Invalid value: "-1s": promotionPause must not be negative`)
+
+	spec.Rollout = &spinv1alpha1.ProgressiveRollout{Canary: &spinv1alpha1.CanaryRollout{}}
+	require.EqualError(t, validateRollout(spec, deploymentfullExecutor),
+		"spec.rollout.canary.steps: Required value: at least one step must be set")
+	spec.Rollout.Canary.Steps = []spinv1alpha1.CanaryStep{{Weight: 10}, {Weight: 0}}
+	require.EqualError(t, validateRollout(spec, deploymentfullExecutor),
+		"spec.rollout.canary.steps[1].weight: Invalid value: 0: weight must be between 1 and 100")
+}
+
+func TestValidateSuspend(t *testing.T) {
+	t.Parallel()
+
+	deploymentlessExecutor := &spinv1alpha1.SpinAppExecutor{
+		Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: false},
+	}
+	deploymentfullExecutor := &spinv1alpha1.SpinAppExecutor{
+		Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true},
+	}
+
+	spec := spinv1alpha1.SpinAppSpec{Replicas: 3, Suspend: true}
+	require.Nil(t, validateSuspend(spec, deploymentfullExecutor))
+	require.EqualError(t, validateSuspend(spec, deploymentlessExecutor),
+		"spec.suspend: Forbidden: suspend can't be set when the executor does not use operator deployments")
+
+	// Autoscaled apps can be suspended
+	spec = spinv1alpha1.SpinAppSpec{
+		Suspend:           true,
+		EnableAutoscaling: true,
+		Autoscaling:       &spinv1alpha1.Autoscaling{MaxReplicas: 3},
+	}
+	require.Nil(t, validateSuspend(spec, deploymentfullExecutor))
+	require.Nil(t, validateReplicas(spec, nil))
+}
+
+func TestValidateDisruptionBudget(t *testing.T) {
+	t.Parallel()
+
+	deploymentlessExecutor := &spinv1alpha1.SpinAppExecutor{
+		Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: false},
+	}
+	deploymentfullExecutor := &spinv1alpha1.SpinAppExecutor{
+		Spec: spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true},
+	}
+	budget := func(minAvailable, maxUnavailable string) *spinv1alpha1.DisruptionBudget {
+		b := &spinv1alpha1.DisruptionBudget{}
+		if minAvailable != "" {
+			b.MinAvailable = generics.Ptr(intstr.Parse(minAvailable))
+		}
+		if maxUnavailable != "" {
+			b.MaxUnavailable = generics.Ptr(intstr.Parse(maxUnavailable))
+		}
+		return b
+	}
+
+	spec := spinv1alpha1.SpinAppSpec{Replicas: 3}
+	require.Nil(t, validateDisruptionBudget(spec, deploymentlessExecutor))
+
+	spec.DisruptionBudget = budget("", "1")
+	require.Nil(t, validateDisruptionBudget(spec, deploymentfullExecutor))
+	require.EqualError(t, validateDisruptionBudget(spec, deploymentlessExecutor),
+		"spec.disruptionBudget: Forbidden: disruptionBudget can't be set when the executor does not use operator deployments")
+
+	spec.DisruptionBudget = budget("", "")
+	require.ErrorContains(t, validateDisruptionBudget(spec, deploymentfullExecutor),
+		"exactly one of minAvailable or maxUnavailable must be set")
+	spec.DisruptionBudget = budget("1", "1")
+	require.ErrorContains(t, validateDisruptionBudget(spec, deploymentfullExecutor),
+		"exactly one of minAvailable or maxUnavailable must be set")
+
+	// maxUnavailable must allow an eviction regardless of the replica count
+	spec.DisruptionBudget = budget("", "10%")
+	require.Nil(t, validateDisruptionBudget(spec, deploymentfullExecutor))
+	spec.DisruptionBudget = budget("", "0%")
+	require.EqualError(t, validateDisruptionBudget(spec, deploymentfullExecutor),
+		`spec.disruptionBudget.maxUnavailable: Invalid value: "0%": maxUnavailable must be a positive number or a percentage between 1% and 100%`)
+	spec.DisruptionBudget = budget("", "abc")
+	require.ErrorContains(t, validateDisruptionBudget(spec, deploymentfullExecutor), "spec.disruptionBudget.maxUnavailable: Invalid value")
+
+	// minAvailable must be lower than the number of replicas
+	spec.DisruptionBudget = budget("2", "")
+	require.Nil(t, validateDisruptionBudget(spec, deploymentfullExecutor))
+	spec.DisruptionBudget = budget("3", "")
+	require.EqualError(t, validateDisruptionBudget(spec, deploymentfullExecutor),
+		`spec.disruptionBudget.minAvailable: Invalid value: "3": minAvailable must leave at least one of the app's 3 replica(s) evictable`)
+	spec.DisruptionBudget = budget("", "")
+	spec.DisruptionBudget.MinAvailable = generics.Ptr(intstr.FromInt32(3))
+	require.EqualError(t, validateDisruptionBudget(spec, deploymentfullExecutor),
+		`spec.disruptionBudget.minAvailable: Invalid value: "3": minAvailable must leave at least one of the app's 3 replica(s) evictable`)
+
+	// Percentages are rounded up
+	spec.DisruptionBudget = budget("60%", "")
+	require.Nil(t, validateDisruptionBudget(spec, deploymentfullExecutor))
+	spec.DisruptionBudget = budget("70%", "")
+	require.ErrorContains(t, validateDisruptionBudget(spec, deploymentfullExecutor), "minAvailable must leave at least one")
+
+	// The minimum replicas of operator managed autoscaling are used
+	spec.Replicas = 0
+	spec.EnableAutoscaling = true
+	spec.Autoscaling = &spinv1alpha1.Autoscaling{MaxReplicas: 10}
+	spec.DisruptionBudget = budget("1", "")
+	require.ErrorContains(t, validateDisruptionBudget(spec, deploymentfullExecutor), "of the app's 1 replica(s) evictable")
+	spec.Autoscaling.MinReplicas = generics.Ptr(int32(2))
+	require.Nil(t, validateDisruptionBudget(spec, deploymentfullExecutor))
+
+	// The replica count of externally autoscaled apps is unknown
+	spec.Autoscaling = nil
+	spec.DisruptionBudget = budget("5", "")
+	require.Nil(t, validateDisruptionBudget(spec, deploymentfullExecutor))
+}
+
+func TestSecurityContextWarnings(t *testing.T) {
+	t.Parallel()
+
+	executor := &spinv1alpha1.SpinAppExecutor{
+		ObjectMeta: metav1.ObjectMeta{Name: "restricted"},
+		Spec: spinv1alpha1.SpinAppExecutorSpec{
+			CreateDeployment: true,
+			DeploymentConfig: &spinv1alpha1.ExecutorDeploymentConfig{
+				PodSecurityContext: &corev1.PodSecurityContext{
+					RunAsNonRoot:   generics.Ptr(true),
+					SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+				},
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: generics.Ptr(false),
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+			},
+		},
+	}
+
+	require.Empty(t, securityContextWarnings(spinv1alpha1.SpinAppSpec{}, executor))
+	require.Empty(t, securityContextWarnings(spinv1alpha1.SpinAppSpec{}, nil))
+
+	// Tightening or restating the defaults is fine
+	spec := spinv1alpha1.SpinAppSpec{
+		PodSecurityContext: &corev1.PodSecurityContext{RunAsUser: generics.Ptr(int64(1000))},
+		SecurityContext: &corev1.SecurityContext{
+			ReadOnlyRootFilesystem: generics.Ptr(true),
+			Capabilities:           &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		},
+	}
+	require.Empty(t, securityContextWarnings(spec, executor))
+
+	spec = spinv1alpha1.SpinAppSpec{
+		PodSecurityContext: &corev1.PodSecurityContext{
+			RunAsUser:      generics.Ptr(int64(0)),
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			Privileged:               generics.Ptr(true),
+			AllowPrivilegeEscalation: generics.Ptr(true),
+			Capabilities:             &corev1.Capabilities{Add: []corev1.Capability{"NET_ADMIN"}, Drop: []corev1.Capability{"ALL"}},
+		},
+	}
+	require.Equal(t, admission.Warnings{
+		`spec.podSecurityContext.runAsNonRoot weakens the security defaults of executor "restricted"`,
+		`spec.podSecurityContext.seccompProfile weakens the security defaults of executor "restricted"`,
+		`spec.securityContext.privileged weakens the security defaults of executor "restricted"`,
+		`spec.securityContext.allowPrivilegeEscalation weakens the security defaults of executor "restricted"`,
+		`spec.securityContext.capabilities weakens the security defaults of executor "restricted"`,
+	}, securityContextWarnings(spec, executor))
+
+	// Not dropping all capabilities replaces the executor default
+	spec = spinv1alpha1.SpinAppSpec{
+		SecurityContext: &corev1.SecurityContext{Capabilities: &corev1.Capabilities{}},
+	}
+	require.Len(t, securityContextWarnings(spec, executor), 1)
+}
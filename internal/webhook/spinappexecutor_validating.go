@@ -2,10 +2,14 @@ package webhook
 
 import (
 	"context"
+	"fmt"
 
 	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/constants"
+	"github.com/spinkube/spin-operator/internal/generics"
 	"github.com/spinkube/spin-operator/internal/logging"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -29,7 +33,7 @@ func (v *SpinAppExecutorValidator) ValidateCreate(ctx context.Context, obj runti
 	executor := obj.(*spinv1alpha1.SpinAppExecutor)
 	log.Info("validate create", "name", executor.Name)
 
-	return nil, v.validateSpinAppExecutor(executor)
+	return nil, v.validateSpinAppExecutor(ctx, executor)
 }
 
 // ValidateUpdate implements webhook.Validator
@@ -39,7 +43,7 @@ func (v *SpinAppExecutorValidator) ValidateUpdate(ctx context.Context, oldObj, n
 	executor := newObj.(*spinv1alpha1.SpinAppExecutor)
 	log.Info("validate update", "name", executor.Name)
 
-	return nil, v.validateSpinAppExecutor(executor)
+	return nil, v.validateSpinAppExecutor(ctx, executor)
 }
 
 // ValidateDelete implements webhook.Validator
@@ -52,12 +56,30 @@ func (v *SpinAppExecutorValidator) ValidateDelete(ctx context.Context, obj runti
 	return nil, nil
 }
 
-func (v *SpinAppExecutorValidator) validateSpinAppExecutor(executor *spinv1alpha1.SpinAppExecutor) error {
+func (v *SpinAppExecutorValidator) validateSpinAppExecutor(ctx context.Context, executor *spinv1alpha1.SpinAppExecutor) error {
 	var allErrs field.ErrorList
 
 	if err := validateRuntimeClassAndSpinImage(&executor.Spec); err != nil {
 		allErrs = append(allErrs, err)
 	}
+	if config := executor.Spec.DeploymentConfig; config != nil {
+		if err := validateRolloutControls(field.NewPath("spec").Child("deploymentConfig"),
+			config.RolloutControls, spinv1alpha1.RolloutControls{}); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	if isDefaultExecutor(executor) {
+		var executors spinv1alpha1.SpinAppExecutorList
+		if err := v.Client.List(ctx, &executors, client.InNamespace(executor.Namespace)); err != nil {
+			return err
+		}
+
+		others := generics.MapList(executors.Items, func(e spinv1alpha1.SpinAppExecutor) metav1.Object { return &e })
+		if err := validateSingleDefaultExecutor(executor, others); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -84,3 +106,24 @@ func validateRuntimeClassAndSpinImage(spec *spinv1alpha1.SpinAppExecutorSpec) *f
 
 	return nil
 }
+
+// validateSingleDefaultExecutor ensures that at most one executor in a scope
+// (a namespace, or the cluster) is marked as the default.
+func validateSingleDefaultExecutor(executor metav1.Object, executorsInScope []metav1.Object) *field.Error {
+	if !isDefaultExecutor(executor) {
+		return nil
+	}
+
+	for _, other := range executorsInScope {
+		if other.GetName() == executor.GetName() || !isDefaultExecutor(other) {
+			continue
+		}
+
+		return field.Invalid(
+			field.NewPath("metadata").Child("annotations").Key(constants.DefaultExecutorAnnotationKey),
+			executor.GetAnnotations()[constants.DefaultExecutorAnnotationKey],
+			fmt.Sprintf("executor %q is already marked as the default", other.GetName()))
+	}
+
+	return nil
+}
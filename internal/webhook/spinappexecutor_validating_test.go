@@ -4,8 +4,10 @@ import (
 	"testing"
 
 	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-operator/internal/constants"
 	"github.com/spinkube/spin-operator/internal/generics"
 	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestValidateRuntimeClassAndSpinImage(t *testing.T) {
@@ -47,3 +49,26 @@ func TestValidateRuntimeClassAndSpinImage(t *testing.T) {
 	})
 	require.EqualError(t, fldErr, "spec.deploymentConfig.runtimeClassName: Invalid value: \"null\": either runtimeClassName or spinImage must be set")
 }
+
+func TestValidateSingleDefaultExecutor(t *testing.T) {
+	t.Parallel()
+
+	executor := func(name string, isDefault bool) metav1.Object {
+		e := &spinv1alpha1.SpinAppExecutor{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if isDefault {
+			e.Annotations = map[string]string{constants.DefaultExecutorAnnotationKey: "true"}
+		}
+		return e
+	}
+
+	// Executors that aren't marked as the default are always allowed
+	fldErr := validateSingleDefaultExecutor(executor("foo", false), []metav1.Object{executor("bar", true)})
+	require.Nil(t, fldErr)
+
+	// The executor itself is ignored on update
+	fldErr = validateSingleDefaultExecutor(executor("foo", true), []metav1.Object{executor("foo", true), executor("bar", false)})
+	require.Nil(t, fldErr)
+
+	fldErr = validateSingleDefaultExecutor(executor("foo", true), []metav1.Object{executor("bar", true)})
+	require.EqualError(t, fldErr, "metadata.annotations[core.spinkube.dev/is-default-executor]: Invalid value: \"true\": executor \"bar\" is already marked as the default")
+}
@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+type warningsKey struct{}
+
+// warningCollector gathers warnings raised while handling an admission request.
+type warningCollector struct {
+	mu       sync.Mutex
+	warnings admission.Warnings
+}
+
+// addWarning records a warning to be returned to the client with the admission
+// response. It is a no-op when the context isn't handled by a warningHandler.
+func addWarning(ctx context.Context, warning string) {
+	collector, ok := ctx.Value(warningsKey{}).(*warningCollector)
+	if !ok {
+		return
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	collector.warnings = append(collector.warnings, warning)
+}
+
+// warningHandler wraps an admission.Handler to return any warnings recorded
+// with addWarning. This is needed because controller-runtime's CustomDefaulter
+// has no way to return warnings.
+type warningHandler struct {
+	admission.Handler
+}
+
+// Handle implements admission.Handler
+func (h warningHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	collector := &warningCollector{}
+	resp := h.Handler.Handle(context.WithValue(ctx, warningsKey{}, collector), req)
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	resp.Warnings = append(resp.Warnings, collector.warnings...)
+	return resp
+}
@@ -17,11 +17,24 @@ const (
 
 	// StatusReady is the ready value for an app status label.
 	StatusReady = "ready"
+
+	// RolloutTrackStable and RolloutTrackCanary are the tracks of an app with a
+	// progressive rollout.
+	RolloutTrackStable = "stable"
+	RolloutTrackCanary = "canary"
 )
 
 var (
 	// NameLabelKey is the app name label key.
 	NameLabelKey = constants.ConstructResourceLabelKey("app-name")
+
+	// ConfigChecksumAnnotationKey is the pod template annotation key holding a
+	// checksum of the Secrets and ConfigMaps an app depends on.
+	ConfigChecksumAnnotationKey = constants.ConstructResourceLabelKey("config-checksum")
+
+	// ImageAnnotationKey is the Deployment annotation key holding the app
+	// image that the Deployment runs.
+	ImageAnnotationKey = constants.ConstructResourceLabelKey("image")
 )
 
 // ConstructStatusLabelKey returns the app status label key, used primarily